@@ -1,22 +1,49 @@
--- Create Categories Table
+-- Create Categories Table (parent_id nests categories; the application
+-- prevents cycles). color, icon and position lay out the POS grid.
 CREATE TABLE IF NOT EXISTS categories (
     id SERIAL PRIMARY KEY,
     name VARCHAR(100) NOT NULL,
     description TEXT,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE SET NULL CHECK (parent_id <> id),
+    color VARCHAR(7),
+    icon VARCHAR(50),
+    position INTEGER,
+    archived_at TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Create Products Table
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+-- Category names are unique regardless of case
+CREATE UNIQUE INDEX IF NOT EXISTS categories_name_lower_key ON categories (LOWER(name));
+
+-- Create Products Table (favorite_order is set on products pinned to the
+-- quick-access bar of the POS)
 CREATE TABLE IF NOT EXISTS products (
     id SERIAL PRIMARY KEY,
     nama VARCHAR(100) NOT NULL,
     harga INTEGER NOT NULL,
     stok INTEGER NOT NULL DEFAULT 0,
+    tipe VARCHAR(20) NOT NULL DEFAULT 'standard',
+    sku VARCHAR(50) UNIQUE,
+    base_unit VARCHAR(20) NOT NULL DEFAULT 'pcs',
+    parent_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    variant_key VARCHAR(255),
     category_id INTEGER,
+    image_key VARCHAR(255),
+    thumbnail_key VARCHAR(255),
+    color VARCHAR(7),
+    icon VARCHAR(50),
+    position INTEGER,
+    favorite_order INTEGER,
+    archived_at TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
+    UNIQUE (parent_id, variant_key)
 );
 
 -- Create Index for better join performance
@@ -50,9 +77,40 @@ CREATE TRIGGER update_categories_updated_at BEFORE UPDATE ON categories
 CREATE TRIGGER update_products_updated_at BEFORE UPDATE ON products
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+-- Create function to bump the row version used as the ETag. Every update,
+-- including stock changes from checkout, produces a new version.
+CREATE OR REPLACE FUNCTION increment_version_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER increment_categories_version BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION increment_version_column();
+
+CREATE TRIGGER increment_products_version BEFORE UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION increment_version_column();
+
+-- Create Customers Table
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    phone VARCHAR(30) NOT NULL DEFAULT '',
+    customer_group VARCHAR(50),
+    credit_limit INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_customers_updated_at BEFORE UPDATE ON customers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
 -- Create Transactions Table
 CREATE TABLE IF NOT EXISTS transactions (
     id SERIAL PRIMARY KEY,
+    customer_id INT REFERENCES customers(id),
     total_amount INT NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -63,6 +121,12 @@ CREATE TABLE IF NOT EXISTS transaction_details (
     transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
     product_id INT REFERENCES products(id),
     quantity INT NOT NULL,
+    unit VARCHAR(20) NOT NULL DEFAULT 'pcs',
+    base_quantity INT NOT NULL,
+    unit_price INT NOT NULL DEFAULT 0,
+    price_list_id INT,
+    pricing_rule_id INT,
+    discount INT NOT NULL DEFAULT 0,
     subtotal INT NOT NULL
 );
 
@@ -73,3 +137,327 @@ CREATE INDEX IF NOT EXISTS idx_transaction_details_product_id
     ON transaction_details(product_id);
 CREATE INDEX IF NOT EXISTS idx_transactions_created_at
     ON transactions(created_at);
+
+
+-- Create Transaction Payments Table (tenders used to settle a transaction)
+CREATE TABLE IF NOT EXISTS transaction_payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL,
+    amount INT NOT NULL,
+    gift_card_code VARCHAR(50)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_transaction_id
+    ON transaction_payments(transaction_id);
+
+-- Create Customer Ledger Table (kasbon). Balances are always derived from
+-- this table: charge entries increase the receivable, payment entries reduce it.
+CREATE TABLE IF NOT EXISTS customer_ledger (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    entry_type VARCHAR(20) NOT NULL CHECK (entry_type IN ('charge', 'payment')),
+    amount INT NOT NULL CHECK (amount > 0),
+    transaction_id INT REFERENCES transactions(id),
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_ledger_customer_id
+    ON customer_ledger(customer_id);
+
+-- Create Gift Cards Table
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    product_id INT REFERENCES products(id),
+    initial_balance INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create Gift Card Movements Table. A card's balance is the sum of its
+-- movements: activations are positive, redemptions negative.
+CREATE TABLE IF NOT EXISTS gift_card_movements (
+    id SERIAL PRIMARY KEY,
+    gift_card_id INT NOT NULL REFERENCES gift_cards(id),
+    movement_type VARCHAR(20) NOT NULL CHECK (movement_type IN ('activation', 'redemption')),
+    amount INT NOT NULL,
+    transaction_id INT REFERENCES transactions(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_card_movements_gift_card_id
+    ON gift_card_movements(gift_card_id);
+
+-- Create Price Lists Table. A list with a NULL customer_group applies to
+-- every customer (e.g. carton prices for walk-ins).
+CREATE TABLE IF NOT EXISTS price_lists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    customer_group VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_price_lists_updated_at BEFORE UPDATE ON price_lists
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Create Price List Items Table (quantity-break tiers per product)
+CREATE TABLE IF NOT EXISTS price_list_items (
+    id SERIAL PRIMARY KEY,
+    price_list_id INT NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    min_qty INT NOT NULL DEFAULT 1 CHECK (min_qty > 0),
+    harga INT NOT NULL CHECK (harga >= 0),
+    UNIQUE (price_list_id, product_id, min_qty)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_items_product_id
+    ON price_list_items(product_id);
+
+-- transaction_details is created before price_lists, so the foreign key is
+-- added here, once
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_transaction_details_price_list') THEN
+        ALTER TABLE transaction_details
+            ADD CONSTRAINT fk_transaction_details_price_list
+            FOREIGN KEY (price_list_id) REFERENCES price_lists(id) ON DELETE SET NULL;
+    END IF;
+END $$;
+
+-- Create Product Price History Table. The price of a product at time T is
+-- the row with the latest effective_from <= T whose effective_to is NULL or
+-- after T. Future rows are applied to products.harga by the price scheduler.
+CREATE TABLE IF NOT EXISTS product_price_history (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    harga INT NOT NULL CHECK (harga >= 0),
+    effective_from TIMESTAMPTZ NOT NULL,
+    effective_to TIMESTAMPTZ,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (effective_to IS NULL OR effective_to > effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_price_history_product_effective
+    ON product_price_history(product_id, effective_from);
+
+-- Seed price history with the current price of products that have none yet
+INSERT INTO product_price_history (product_id, harga, effective_from)
+SELECT id, harga, created_at FROM products
+WHERE NOT EXISTS (SELECT 1 FROM product_price_history h WHERE h.product_id = products.id);
+
+-- Create Pricing Rules Table (recurring time-window discounts such as happy
+-- hour). days holds ISO weekdays (1 = Monday, 7 = Sunday); a window whose
+-- end_time is before start_time runs past midnight.
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    product_id INT REFERENCES products(id) ON DELETE CASCADE,
+    category_id INT REFERENCES categories(id) ON DELETE CASCADE,
+    days INTEGER[] NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    discount_percent INT NOT NULL CHECK (discount_percent BETWEEN 1 AND 100),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((product_id IS NULL) <> (category_id IS NULL))
+);
+
+CREATE TRIGGER update_pricing_rules_updated_at BEFORE UPDATE ON pricing_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_transaction_details_pricing_rule') THEN
+        ALTER TABLE transaction_details
+            ADD CONSTRAINT fk_transaction_details_pricing_rule
+            FOREIGN KEY (pricing_rule_id) REFERENCES pricing_rules(id) ON DELETE SET NULL;
+    END IF;
+END $$;
+
+-- Create Product Barcodes Table (EAN-13, UPC-A or EAN-8, one or more per product)
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    barcode VARCHAR(13) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id
+    ON product_barcodes(product_id);
+
+-- Create Product Variant Attributes Table (option values of a variant, e.g.
+-- Ukuran = L). Variants are rows in products with parent_id set.
+CREATE TABLE IF NOT EXISTS product_variant_attributes (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    value VARCHAR(50) NOT NULL,
+    PRIMARY KEY (product_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_parent_id ON products(parent_id);
+
+-- Create Product Units Table. conversion is the number of base units in one
+-- of this unit (e.g. 1 karton = 12 botol); stock is always kept in base units.
+CREATE TABLE IF NOT EXISTS product_units (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(20) NOT NULL,
+    conversion INT NOT NULL CHECK (conversion > 0),
+    harga INT CHECK (harga >= 0),
+    UNIQUE (product_id, name)
+);
+
+-- Create Bundle Components Table. Selling a bundle deducts quantity (in the
+-- component's base unit) per bundle from each component's stock.
+CREATE TABLE IF NOT EXISTS bundle_components (
+    bundle_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    component_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, component_id),
+    CHECK (bundle_id <> component_id)
+);
+
+-- Create Transaction Detail Components Table (stock consumed by a bundle
+-- line and the share of its revenue attributed to each component)
+CREATE TABLE IF NOT EXISTS transaction_detail_components (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL,
+    revenue INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_components_detail_id
+    ON transaction_detail_components(transaction_detail_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_components_product_id
+    ON transaction_detail_components(product_id);
+
+-- Create Recipe Items Table. Selling a product with a recipe deducts
+-- quantity (in the ingredient's base unit) per unit sold from each
+-- ingredient instead of the product's own stock.
+CREATE TABLE IF NOT EXISTS recipe_items (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    ingredient_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (product_id, ingredient_id),
+    CHECK (product_id <> ingredient_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_items_ingredient_id ON recipe_items(ingredient_id);
+
+-- Create Transaction Detail Ingredients Table (ingredient stock consumed by
+-- a recipe line, used for theoretical usage)
+CREATE TABLE IF NOT EXISTS transaction_detail_ingredients (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_ingredients_detail_id
+    ON transaction_detail_ingredients(transaction_detail_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_ingredients_product_id
+    ON transaction_detail_ingredients(product_id);
+
+-- Create Stock Counts Table. system_qty is the stock before the count;
+-- recording a count sets products.stok to counted_qty.
+CREATE TABLE IF NOT EXISTS stock_counts (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    system_qty INT NOT NULL,
+    counted_qty INT NOT NULL CHECK (counted_qty >= 0),
+    note TEXT,
+    counted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_counts_product_id ON stock_counts(product_id, counted_at);
+
+-- Create Modifier Groups Table. min_select > 0 makes the group required;
+-- max_select = 0 means no upper limit.
+CREATE TABLE IF NOT EXISTS modifier_groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    min_select INT NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select INT NOT NULL DEFAULT 0 CHECK (max_select >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (max_select = 0 OR max_select >= min_select)
+);
+
+CREATE TRIGGER update_modifier_groups_updated_at BEFORE UPDATE ON modifier_groups
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Create Modifiers Table. product_id optionally links a modifier to stock
+-- (e.g. "tambah telur" deducts quantity eggs per unit sold).
+CREATE TABLE IF NOT EXISTS modifiers (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    price_delta INT NOT NULL DEFAULT 0,
+    product_id INT REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_modifiers_group_id ON modifiers(group_id);
+
+-- Create Product Modifier Groups Table
+CREATE TABLE IF NOT EXISTS product_modifier_groups (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (product_id, group_id)
+);
+
+-- Create Transaction Detail Modifiers Table. Names and prices are copied so
+-- receipts stay accurate after a modifier is changed or deleted.
+CREATE TABLE IF NOT EXISTS transaction_detail_modifiers (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    modifier_id INT REFERENCES modifiers(id) ON DELETE SET NULL,
+    group_name VARCHAR(100) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    price_delta INT NOT NULL,
+    product_id INT REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_modifiers_detail_id
+    ON transaction_detail_modifiers(transaction_detail_id);
+
+-- Product search: full-text prefix matching on nama, trigram similarity for
+-- typos and SKU/barcode prefix lookups
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_products_nama_fts
+    ON products USING GIN (to_tsvector('simple', nama));
+CREATE INDEX IF NOT EXISTS idx_products_nama_trgm
+    ON products USING GIN (nama gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_sku_trgm
+    ON products USING GIN (sku gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_barcode_pattern
+    ON product_barcodes (barcode text_pattern_ops);
+
+-- Create Bulk Updates Tables: one audit row per committed bulk price update
+-- or stock count, with the before and after value of every product changed
+CREATE TABLE IF NOT EXISTS bulk_updates (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('price', 'stock')),
+    params JSONB NOT NULL,
+    note TEXT,
+    item_count INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bulk_update_items (
+    id SERIAL PRIMARY KEY,
+    bulk_update_id INT NOT NULL REFERENCES bulk_updates(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    before_value INT NOT NULL,
+    after_value INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_update_items_bulk_update_id ON bulk_update_items(bulk_update_id);
@@ -0,0 +1,130 @@
+package bulkupdate
+
+import (
+	"belajar-go/pkg/validation"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	KindPrice = "price"
+	KindStock = "stock"
+)
+
+const (
+	PriceModePercent = "percent"
+	PriceModeAmount  = "amount"
+)
+
+// BulkUpdate is the audit record of one committed bulk operation. Params is
+// the request that produced it.
+type BulkUpdate struct {
+	ID        int             `json:"id"`
+	Kind      string          `json:"kind"`
+	Params    json.RawMessage `json:"params"`
+	Note      *string         `json:"note"`
+	ItemCount int             `json:"item_count"`
+	Items     []Change        `json:"items,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Change is the value of one product before and after a bulk operation:
+// harga for price updates, stok for stock counts.
+type Change struct {
+	ProductID int    `json:"product_id"`
+	Nama      string `json:"nama"`
+	Before    int    `json:"before"`
+	After     int    `json:"after"`
+}
+
+// Result is returned by a bulk operation. ID is the audit record, nil in a
+// dry run.
+type Result struct {
+	DryRun bool     `json:"dry_run"`
+	ID     *int     `json:"id"`
+	Count  int      `json:"count"`
+	Items  []Change `json:"items"`
+}
+
+// ProductFilter selects the products of a price update. All given criteria
+// must match; archived products are never changed. CategoryID includes the
+// products of its subcategories.
+type ProductFilter struct {
+	CategoryID *int   `json:"category_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	IDs        []int  `json:"ids,omitempty"`
+}
+
+// PriceUpdateRequest changes harga by Value percent or by Value rupiah.
+// The new price is rounded to the nearest multiple of RoundTo, if set.
+type PriceUpdateRequest struct {
+	Filter  ProductFilter `json:"filter"`
+	Mode    string        `json:"mode"`
+	Value   float64       `json:"value"`
+	RoundTo int           `json:"round_to,omitempty"`
+	Note    *string       `json:"note,omitempty"`
+}
+
+func (req PriceUpdateRequest) Validate() validation.Errors {
+	var v validation.Validator
+	f := req.Filter
+	v.Check(f.CategoryID != nil || f.Name != "" || len(f.IDs) > 0, "filter", validation.CodeRequired,
+		"filter needs at least one of category_id, name or ids")
+	for i, id := range f.IDs {
+		v.Min(fmt.Sprintf("filter.ids[%d]", i), id, 1)
+	}
+	v.OneOf("mode", req.Mode, PriceModePercent, PriceModeAmount)
+	switch req.Mode {
+	case PriceModePercent:
+		v.Check(req.Value >= -100, "value", validation.CodeMin, "value must be at least -100")
+	case PriceModeAmount:
+		v.Check(req.Value == math.Trunc(req.Value), "value", validation.CodeInvalid, "value must be a whole number")
+	}
+	v.Check(req.Value != 0, "value", validation.CodeInvalid, "value cannot be 0")
+	v.Min("round_to", req.RoundTo, 0)
+	return v.Errors()
+}
+
+// Apply returns the new price for harga.
+func (req PriceUpdateRequest) Apply(harga int) int {
+	var price float64
+	if req.Mode == PriceModePercent {
+		price = float64(harga) * (100 + req.Value) / 100
+	} else {
+		price = float64(harga) + req.Value
+	}
+
+	if req.RoundTo > 1 {
+		return int(math.Round(price/float64(req.RoundTo))) * req.RoundTo
+	}
+	return int(math.Round(price))
+}
+
+// StockCountItem is the counted stock of one product.
+type StockCountItem struct {
+	ProductID  int `json:"product_id"`
+	CountedQty int `json:"counted_qty"`
+}
+
+// StockUpdateRequest records a stock count for many products at once and
+// sets their stock to the counted quantities.
+type StockUpdateRequest struct {
+	Items []StockCountItem `json:"items"`
+	Note  *string          `json:"note,omitempty"`
+}
+
+func (req StockUpdateRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Check(len(req.Items) > 0, "items", validation.CodeRequired, "items cannot be empty")
+	seen := make(map[int]bool)
+	for i, item := range req.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		v.Min(field+".product_id", item.ProductID, 1)
+		v.Check(!seen[item.ProductID], field+".product_id", validation.CodeDuplicate, "duplicate product_id")
+		v.Min(field+".counted_qty", item.CountedQty, 0)
+		seen[item.ProductID] = true
+	}
+	return v.Errors()
+}
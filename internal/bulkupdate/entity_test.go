@@ -0,0 +1,38 @@
+package bulkupdate
+
+import "testing"
+
+func TestPriceUpdateRequestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		value   float64
+		roundTo int
+		harga   int
+		want    int
+	}{
+		{"percent increase", PriceModePercent, 10, 0, 3500, 3850},
+		{"percent decrease", PriceModePercent, -10, 0, 3500, 3150},
+		{"fractional percent", PriceModePercent, 12.5, 0, 1000, 1125},
+		{"percent rounds half up", PriceModePercent, 5, 0, 1010, 1061},
+		{"minus 100 percent", PriceModePercent, -100, 0, 3500, 0},
+		{"percent rounded to 500", PriceModePercent, 10, 500, 3500, 4000},
+		{"percent rounded down to 500", PriceModePercent, 5, 500, 3500, 3500},
+		{"round_to midpoint rounds up", PriceModePercent, 50, 500, 2500, 4000},
+		{"percent rounded to 100", PriceModePercent, -10, 100, 3500, 3200},
+		{"round_to 1 does not round", PriceModePercent, 5, 1, 1010, 1061},
+		{"amount increase", PriceModeAmount, 250, 0, 3500, 3750},
+		{"amount decrease", PriceModeAmount, -500, 0, 3500, 3000},
+		{"amount rounded to 1000", PriceModeAmount, 250, 1000, 3500, 4000},
+		{"amount below zero", PriceModeAmount, -4000, 0, 3500, -500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := PriceUpdateRequest{Mode: tt.mode, Value: tt.value, RoundTo: tt.roundTo}
+			if got := req.Apply(tt.harga); got != tt.want {
+				t.Errorf("Apply(%d) = %d, want %d", tt.harga, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package bulkupdate
+
+import (
+	"belajar-go/pkg/response"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	updates, err := h.service.GetAll()
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, updates)
+}
+
+func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	update, err := h.service.GetByID(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, update)
+}
+
+// UpdatePrices changes harga of every product matching the filter. With
+// ?dry_run=true it only returns the before and after prices.
+func (h *Handler) UpdatePrices(w http.ResponseWriter, r *http.Request) {
+	var req PriceUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	result, err := h.service.UpdatePrices(req, dryRun)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, result)
+}
+
+// UpdateStock sets stok from a stock count of many products. With
+// ?dry_run=true it only returns the system and counted quantities.
+func (h *Handler) UpdateStock(w http.ResponseWriter, r *http.Request) {
+	var req StockUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	result, err := h.service.UpdateStock(req, dryRun)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, result)
+}
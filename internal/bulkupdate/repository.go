@@ -0,0 +1,298 @@
+package bulkupdate
+
+import (
+	"belajar-go/internal/category"
+	"belajar-go/internal/product"
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/validation"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	GetAll() ([]BulkUpdate, error)
+	GetByID(id int) (*BulkUpdate, error)
+	UpdatePrices(req PriceUpdateRequest, dryRun bool) (*Result, error)
+	UpdateStock(req StockUpdateRequest, dryRun bool) (*Result, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]BulkUpdate, error) {
+	rows, err := r.db.Query(`
+		SELECT id, kind, params, note, item_count, created_at
+		FROM bulk_updates
+		ORDER BY created_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	updates := make([]BulkUpdate, 0)
+	for rows.Next() {
+		var u BulkUpdate
+		if err := rows.Scan(&u.ID, &u.Kind, &u.Params, &u.Note, &u.ItemCount, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		updates = append(updates, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+func (r *repository) GetByID(id int) (*BulkUpdate, error) {
+	var u BulkUpdate
+	err := r.db.QueryRow(`
+		SELECT id, kind, params, note, item_count, created_at
+		FROM bulk_updates WHERE id = $1
+	`, id).Scan(&u.ID, &u.Kind, &u.Params, &u.Note, &u.ItemCount, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("bulk update not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT i.product_id, p.nama, i.before_value, i.after_value
+		FROM bulk_update_items i
+		JOIN products p ON i.product_id = p.id
+		WHERE i.bulk_update_id = $1
+		ORDER BY i.product_id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	u.Items = make([]Change, 0)
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.ProductID, &c.Nama, &c.Before, &c.After); err != nil {
+			return nil, err
+		}
+		u.Items = append(u.Items, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// UpdatePrices locks the matching products, computes their new prices and,
+// unless dryRun, saves them together with price history and the audit
+// record. Products whose price would not change are left out.
+func (r *repository) UpdatePrices(req PriceUpdateRequest, dryRun bool) (*Result, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT id, nama, harga FROM products WHERE archived_at IS NULL"
+	args := []interface{}{}
+	if req.Filter.CategoryID != nil {
+		args = append(args, *req.Filter.CategoryID)
+		query += fmt.Sprintf(" AND category_id IN ("+category.SubtreeIDs+")", len(args))
+	}
+	if req.Filter.Name != "" {
+		args = append(args, "%"+req.Filter.Name+"%")
+		query += fmt.Sprintf(" AND nama ILIKE $%d", len(args))
+	}
+	if len(req.Filter.IDs) > 0 {
+		args = append(args, pq.Array(req.Filter.IDs))
+		query += fmt.Sprintf(" AND id = ANY($%d)", len(args))
+	}
+	query += " ORDER BY id FOR UPDATE"
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{DryRun: dryRun, Items: make([]Change, 0)}
+	var v validation.Validator
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.ProductID, &c.Nama, &c.Before); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.After = req.Apply(c.Before)
+		if c.After < 0 {
+			v.Add("value", validation.CodeMin, fmt.Sprintf("harga of %s would become negative", c.Nama))
+			continue
+		}
+		if c.After != c.Before {
+			result.Items = append(result.Items, c)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if errs := v.Errors(); errs != nil {
+		return nil, errs
+	}
+	result.Count = len(result.Items)
+
+	if dryRun || result.Count == 0 {
+		return result, nil
+	}
+
+	for _, c := range result.Items {
+		if _, err := tx.Exec("UPDATE products SET harga = $1 WHERE id = $2", c.After, c.ProductID); err != nil {
+			return nil, err
+		}
+		if err := product.RecordPrice(tx, c.ProductID, c.After, product.PriceSourceBulk); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeAudit(tx, result, KindPrice, req, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateStock records a stock count per item and sets the product stock to
+// the counted quantity. Like a single stock count, bundles and recipe
+// products are rejected because their stock is derived from their parts.
+func (r *repository) UpdateStock(req StockUpdateRequest, dryRun bool) (*Result, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, len(req.Items))
+	for i, item := range req.Items {
+		ids[i] = item.ProductID
+	}
+
+	rows, err := tx.Query(`
+		SELECT p.id, p.nama, p.stok, p.tipe = $2 OR EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id)
+		FROM products p
+		WHERE p.id = ANY($1)
+		ORDER BY p.id
+		FOR UPDATE`, pq.Array(ids), product.TypeBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	type current struct {
+		nama    string
+		stok    int
+		derived bool
+	}
+	products := make(map[int]current)
+	for rows.Next() {
+		var id int
+		var c current
+		if err := rows.Scan(&id, &c.nama, &c.stok, &c.derived); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		products[id] = c
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{DryRun: dryRun, Items: make([]Change, 0, len(req.Items))}
+	var v validation.Validator
+	for i, item := range req.Items {
+		field := fmt.Sprintf("items[%d].product_id", i)
+		p, ok := products[item.ProductID]
+		if !ok {
+			v.Add(field, validation.CodeNotFound, fmt.Sprintf("product id %d not found", item.ProductID))
+			continue
+		}
+		if p.derived {
+			v.Add(field, validation.CodeInvalid, fmt.Sprintf("stock of %s is derived from its components and cannot be counted", p.nama))
+			continue
+		}
+		result.Items = append(result.Items, Change{ProductID: item.ProductID, Nama: p.nama, Before: p.stok, After: item.CountedQty})
+	}
+	if errs := v.Errors(); errs != nil {
+		return nil, errs
+	}
+	result.Count = len(result.Items)
+
+	if dryRun {
+		return result, nil
+	}
+
+	for _, c := range result.Items {
+		_, err := tx.Exec(
+			"INSERT INTO stock_counts (product_id, system_qty, counted_qty, note) VALUES ($1, $2, $3, $4)",
+			c.ProductID, c.Before, c.After, req.Note,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE products SET stok = $1 WHERE id = $2", c.After, c.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeAudit(tx, result, KindStock, req.Items, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// writeAudit stores the bulk update and its changes and sets result.ID.
+func writeAudit(tx *sql.Tx, result *Result, kind string, params interface{}, note *string) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	var id int
+	err = tx.QueryRow(
+		"INSERT INTO bulk_updates (kind, params, note, item_count) VALUES ($1, $2, $3, $4) RETURNING id",
+		kind, b, note, result.Count,
+	).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range result.Items {
+		_, err := tx.Exec(
+			"INSERT INTO bulk_update_items (bulk_update_id, product_id, before_value, after_value) VALUES ($1, $2, $3, $4)",
+			id, c.ProductID, c.Before, c.After,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	result.ID = &id
+	return nil
+}
@@ -0,0 +1,35 @@
+package bulkupdate
+
+type Service interface {
+	GetAll() ([]BulkUpdate, error)
+	GetByID(id int) (*BulkUpdate, error)
+	UpdatePrices(req PriceUpdateRequest, dryRun bool) (*Result, error)
+	UpdateStock(req StockUpdateRequest, dryRun bool) (*Result, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetAll() ([]BulkUpdate, error) {
+	return s.repo.GetAll()
+}
+
+func (s *service) GetByID(id int) (*BulkUpdate, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) UpdatePrices(req PriceUpdateRequest, dryRun bool) (*Result, error) {
+	if req.RoundTo == 0 {
+		req.RoundTo = 1
+	}
+	return s.repo.UpdatePrices(req, dryRun)
+}
+
+func (s *service) UpdateStock(req StockUpdateRequest, dryRun bool) (*Result, error) {
+	return s.repo.UpdateStock(req, dryRun)
+}
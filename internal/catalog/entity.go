@@ -0,0 +1,311 @@
+package catalog
+
+import (
+	"belajar-go/internal/product"
+	"belajar-go/pkg/barcode"
+	"belajar-go/pkg/validation"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format and Version identify a catalog document. Version is bumped when the
+// document changes incompatibly; Import rejects versions it does not know.
+const (
+	Format  = "belajar-go-catalog"
+	Version = 1
+)
+
+const (
+	ModeMerge   = "merge"
+	ModeReplace = "replace"
+)
+
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	ActionArchive   = "archive"
+)
+
+// Document is a store catalog that can be imported into another store. Refs
+// are the IDs in the exporting store and only link entries within the
+// document. Stock is not part of the catalog.
+type Document struct {
+	Format     string          `json:"format"`
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Categories []CategoryEntry `json:"categories"`
+	Products   []ProductEntry  `json:"products"`
+}
+
+// CategoryEntry is a category; ParentRef points to the category of the
+// document it is nested under.
+type CategoryEntry struct {
+	Ref         int    `json:"ref"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ParentRef   *int   `json:"parent_ref"`
+}
+
+type ProductEntry struct {
+	Ref         int              `json:"ref"`
+	Nama        string           `json:"nama"`
+	Harga       int              `json:"harga"`
+	Tipe        string           `json:"tipe"`
+	SKU         *string          `json:"sku"`
+	Barcodes    []string         `json:"barcodes"`
+	BaseUnit    string           `json:"base_unit"`
+	CategoryRef *int             `json:"category_ref"`
+	Units       []UnitEntry      `json:"units,omitempty"`
+	Components  []ComponentEntry `json:"components,omitempty"`
+	Recipe      []ComponentEntry `json:"recipe,omitempty"`
+	Variants    []VariantEntry   `json:"variants,omitempty"`
+}
+
+// VariantEntry is a variant of the product it is listed under. Its name,
+// base unit and category follow the parent, as when it is created through
+// the API.
+type VariantEntry struct {
+	Ref      int               `json:"ref"`
+	Harga    int               `json:"harga"`
+	SKU      *string           `json:"sku"`
+	Barcodes []string          `json:"barcodes"`
+	Options  map[string]string `json:"options"`
+}
+
+type UnitEntry struct {
+	Name       string `json:"name"`
+	Conversion int    `json:"conversion"`
+	Harga      *int   `json:"harga"`
+}
+
+// ComponentEntry points to another product of the document, as a bundle
+// component or a recipe ingredient.
+type ComponentEntry struct {
+	Ref      int `json:"ref"`
+	Quantity int `json:"quantity"`
+}
+
+// ImportResult is the plan of an import, and what was applied unless it was
+// a dry run. When there are conflicts nothing is applied.
+type ImportResult struct {
+	Mode       string       `json:"mode"`
+	DryRun     bool         `json:"dry_run"`
+	Applied    bool         `json:"applied"`
+	Categories []ItemResult `json:"categories"`
+	Products   []ItemResult `json:"products"`
+	Conflicts  []Conflict   `json:"conflicts"`
+}
+
+// ItemResult is the action taken for one entry of the document, or for an
+// existing item archived by a replace, which has no Ref. ID is the item in
+// this store; it is nil for items a dry run would create.
+type ItemResult struct {
+	Ref    *int   `json:"ref"`
+	ID     *int   `json:"id"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+// Conflict is an entry that cannot be matched to the existing catalog
+// without guessing, such as a SKU and a name pointing to different products.
+type Conflict struct {
+	Ref    int    `json:"ref"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Validate checks the document on its own: the format, the fields the
+// product and category requests require, and that every ref points to an
+// entry of the document. Matching against the store happens on import.
+func (d Document) Validate() validation.Errors {
+	var v validation.Validator
+	v.Check(d.Format == Format, "format", validation.CodeInvalid, "format must be "+Format)
+	v.Range("version", d.Version, 1, Version)
+
+	// Category names are unique regardless of case, like in the store
+	categoryRefs := make(map[int]bool)
+	categoryNames := make(map[string]bool)
+	for i, c := range d.Categories {
+		field := fmt.Sprintf("categories[%d]", i)
+		v.Check(!categoryRefs[c.Ref], field+".ref", validation.CodeDuplicate, "duplicate category ref")
+		v.Required(field+".name", c.Name)
+		v.Check(!categoryNames[strings.ToLower(c.Name)], field+".name", validation.CodeDuplicate, "duplicate category name "+c.Name)
+		categoryRefs[c.Ref] = true
+		categoryNames[strings.ToLower(c.Name)] = true
+	}
+	validateParents(&v, d.Categories, categoryRefs)
+
+	// Variants are standard products, so they can be bundle components and
+	// recipe ingredients too
+	types := make(map[int]string)
+	for _, p := range d.Products {
+		types[p.Ref] = p.Tipe
+		for _, variant := range p.Variants {
+			types[variant.Ref] = product.TypeStandard
+		}
+	}
+
+	refs := make(map[int]bool)
+	skus := make(map[string]bool)
+	barcodes := make(map[string]bool)
+	checkRef := func(field string, ref int) {
+		v.Check(!refs[ref], field+".ref", validation.CodeDuplicate, "duplicate product ref")
+		refs[ref] = true
+	}
+	checkSKU := func(field string, sku *string) {
+		if sku == nil {
+			return
+		}
+		v.Check(!skus[*sku], field+".sku", validation.CodeDuplicate, "duplicate sku "+*sku)
+		skus[*sku] = true
+	}
+
+	for i, p := range d.Products {
+		field := fmt.Sprintf("products[%d]", i)
+		checkRef(field, p.Ref)
+		checkSKU(field, p.SKU)
+		v.Required(field+".nama", p.Nama)
+		v.Min(field+".harga", p.Harga, 0)
+		v.OneOf(field+".tipe", p.Tipe, product.TypeStandard, product.TypeGiftCard, product.TypeBundle)
+		v.Required(field+".base_unit", p.BaseUnit)
+		validateBarcodes(&v, field, p.Barcodes, barcodes)
+		if p.CategoryRef != nil {
+			v.Check(categoryRefs[*p.CategoryRef], field+".category_ref", validation.CodeNotFound, "category_ref is not a category of the document")
+		}
+
+		units := make(map[string]bool)
+		for j, u := range p.Units {
+			unitField := fmt.Sprintf("%s.units[%d]", field, j)
+			v.Required(unitField+".name", u.Name)
+			v.Check(u.Name != p.BaseUnit, unitField+".name", validation.CodeInvalid, "unit cannot be the base unit")
+			v.Check(!units[u.Name], unitField+".name", validation.CodeDuplicate, "duplicate unit "+u.Name)
+			v.Min(unitField+".conversion", u.Conversion, 1)
+			if u.Harga != nil {
+				v.Min(unitField+".harga", *u.Harga, 0)
+			}
+			units[u.Name] = true
+		}
+
+		v.Check(len(p.Components) == 0 || p.Tipe == product.TypeBundle, field+".components", validation.CodeInvalid, "only bundles have components")
+		validateLinks(&v, field+".components", p.Ref, p.Components, types)
+		v.Check(len(p.Recipe) == 0 || p.Tipe == product.TypeStandard, field+".recipe", validation.CodeInvalid, "only standard products have a recipe")
+		validateLinks(&v, field+".recipe", p.Ref, p.Recipe, types)
+
+		v.Check(len(p.Variants) == 0 || p.Tipe == product.TypeStandard, field+".variants", validation.CodeInvalid, "only standard products have variants")
+		keys := make(map[string]bool)
+		for j, variant := range p.Variants {
+			variantField := fmt.Sprintf("%s.variants[%d]", field, j)
+			checkRef(variantField, variant.Ref)
+			checkSKU(variantField, variant.SKU)
+			v.Min(variantField+".harga", variant.Harga, 0)
+			v.Check(len(variant.Options) > 0, variantField+".options", validation.CodeRequired, "options is required")
+			for name, value := range variant.Options {
+				if name == "" || value == "" {
+					v.Add(variantField+".options", validation.CodeInvalid, "option names and values cannot be empty")
+					break
+				}
+			}
+			key := optionsKey(variant.Options)
+			v.Check(!keys[key], variantField+".options", validation.CodeDuplicate, "duplicate variant options")
+			keys[key] = true
+			validateBarcodes(&v, variantField, variant.Barcodes, barcodes)
+		}
+	}
+
+	return v.Errors()
+}
+
+// validateParents checks that each parent_ref is another category of the
+// document and that following them never leads back to the same category.
+func validateParents(v *validation.Validator, categories []CategoryEntry, refs map[int]bool) {
+	parents := make(map[int]*int, len(categories))
+	for _, c := range categories {
+		parents[c.Ref] = c.ParentRef
+	}
+
+	for i, c := range categories {
+		if c.ParentRef == nil {
+			continue
+		}
+		field := fmt.Sprintf("categories[%d].parent_ref", i)
+		if !refs[*c.ParentRef] || *c.ParentRef == c.Ref {
+			v.Add(field, validation.CodeNotFound, "parent_ref is not another category of the document")
+			continue
+		}
+
+		seen := map[int]bool{c.Ref: true}
+		for ref := c.ParentRef; ref != nil; ref = parents[*ref] {
+			if seen[*ref] {
+				v.Add(field, validation.CodeInvalid, "parent_ref makes a cycle")
+				break
+			}
+			seen[*ref] = true
+		}
+	}
+}
+
+// parentsFirst orders the categories of a valid document so that every
+// category comes after its parent.
+func parentsFirst(categories []CategoryEntry) []CategoryEntry {
+	parents := make(map[int]*int, len(categories))
+	for _, c := range categories {
+		parents[c.Ref] = c.ParentRef
+	}
+	depth := func(c CategoryEntry) int {
+		n := 0
+		for ref := c.ParentRef; ref != nil; ref = parents[*ref] {
+			n++
+		}
+		return n
+	}
+
+	ordered := append([]CategoryEntry(nil), categories...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return depth(ordered[i]) < depth(ordered[j])
+	})
+	return ordered
+}
+
+// validateBarcodes checks each barcode and that it is used only once in the
+// whole document.
+func validateBarcodes(v *validation.Validator, field string, codes []string, seen map[string]bool) {
+	for i, code := range codes {
+		codeField := fmt.Sprintf("%s.barcodes[%d]", field, i)
+		if err := barcode.Validate(code); err != nil {
+			v.Add(codeField, validation.CodeInvalid, err.Error())
+			continue
+		}
+		v.Check(!seen[code], codeField, validation.CodeDuplicate, "duplicate barcode "+code)
+		seen[code] = true
+	}
+}
+
+// validateLinks checks bundle components or recipe ingredients, which must
+// be other standard products of the document. The repositories check the
+// rest of the rules when they are saved.
+func validateLinks(v *validation.Validator, field string, self int, links []ComponentEntry, types map[int]string) {
+	seen := make(map[int]bool)
+	for i, link := range links {
+		linkField := fmt.Sprintf("%s[%d]", field, i)
+		tipe, ok := types[link.Ref]
+		v.Check(ok && link.Ref != self, linkField+".ref", validation.CodeNotFound, "ref is not another product of the document")
+		v.Check(!ok || tipe == product.TypeStandard, linkField+".ref", validation.CodeInvalid, "ref must be a standard product")
+		v.Check(!seen[link.Ref], linkField+".ref", validation.CodeDuplicate, "duplicate ref")
+		v.Min(linkField+".quantity", link.Quantity, 1)
+		seen[link.Ref] = true
+	}
+}
+
+// optionsKey compares variant options case-insensitively, like the unique
+// variant key of the products table.
+func optionsKey(options map[string]string) string {
+	parts := make([]string, 0, len(options))
+	for name, value := range options {
+		parts = append(parts, strings.ToLower(name)+"="+strings.ToLower(value))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,74 @@
+package catalog
+
+import (
+	"belajar-go/pkg/response"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// MaxImportSize is the largest document accepted by Import.
+const MaxImportSize = 20 << 20
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Export returns the active catalog as a document that Import accepts.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	doc, err := h.service.Export()
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, doc)
+}
+
+// Import applies a document from Export. ?mode=replace also archives what
+// the document does not list, and with ?dry_run=true only the plan is
+// returned. Conflicts stop the import with a 409 before anything changes.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize)
+	var doc Document
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := doc.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = ModeMerge
+	}
+	if mode != ModeMerge && mode != ModeReplace {
+		response.Error(w, http.StatusBadRequest, "mode must be merge or replace")
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	result, err := h.service.Import(doc, mode, dryRun)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	if len(result.Conflicts) > 0 && !dryRun {
+		response.JSON(w, http.StatusConflict, response.Response{
+			Success: false,
+			Message: "Import has conflicts, nothing was changed",
+			Data:    result,
+		})
+		return
+	}
+
+	response.Success(w, http.StatusOK, result)
+}
@@ -0,0 +1,586 @@
+package catalog
+
+import (
+	"belajar-go/internal/category"
+	"belajar-go/internal/product"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// plan is what an import does, worked out from the document and the
+// current catalog before anything is changed.
+type plan struct {
+	mode            string
+	index           *index
+	categories      []*categoryStep
+	products        []*productStep
+	staleCategories []category.Category
+	staleProducts   []product.ProductDetail
+	conflicts       []Conflict
+
+	// categoryIDs and productIDs map the refs of the document to IDs in
+	// this store. Items still to be created are missing until they are.
+	categoryIDs map[int]int
+	productIDs  map[int]int
+}
+
+type categoryStep struct {
+	entry    CategoryEntry
+	existing *category.Category
+	action   string
+}
+
+// productStep is one top-level product. units and links are those of the
+// existing product, with product IDs as the refs of links.
+type productStep struct {
+	entry    ProductEntry
+	existing *product.ProductDetail
+	action   string
+	units    []product.ProductUnit
+	links    []ComponentEntry
+	variants []*variantStep
+}
+
+type variantStep struct {
+	entry    VariantEntry
+	existing *product.ProductDetail
+	action   string
+}
+
+// index looks up the existing products by the keys an import matches on.
+type index struct {
+	bySKU    map[string]*product.ProductDetail
+	byName   map[string][]*product.ProductDetail
+	barcodes map[string]int
+	variants map[int][]product.ProductDetail
+}
+
+// plan matches categories by name, products by SKU and name, and variants
+// by SKU and options. An entry that cannot be matched without guessing is
+// reported as a conflict instead of being planned.
+func (s *service) plan(doc Document, mode string) (*plan, error) {
+	categories, err := s.allCategories(category.StatusAll)
+	if err != nil {
+		return nil, err
+	}
+
+	products, variants, err := s.allProducts(product.StatusAll)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{
+		mode:        mode,
+		index:       newIndex(products, variants),
+		categoryIDs: make(map[int]int),
+		productIDs:  make(map[int]int),
+		conflicts:   make([]Conflict, 0),
+	}
+
+	categoriesByName := make(map[string][]*category.Category)
+	for i := range categories {
+		key := nameKey(categories[i].Name)
+		categoriesByName[key] = append(categoriesByName[key], &categories[i])
+	}
+
+	// Parents are planned first, so their IDs are known when comparing and
+	// saving their subcategories
+	claimedCategories := make(map[int]bool)
+	for _, entry := range parentsFirst(doc.Categories) {
+		existing, ok := pickCategory(categoriesByName[nameKey(entry.Name)])
+		if !ok {
+			p.conflict(entry.Ref, entry.Name, "name matches more than one category")
+			continue
+		}
+
+		step := &categoryStep{entry: entry, existing: existing, action: ActionCreate}
+		if existing != nil {
+			if claimedCategories[existing.ID] {
+				p.conflict(entry.Ref, entry.Name, fmt.Sprintf("another category of the document also matches category id %d", existing.ID))
+				continue
+			}
+			claimedCategories[existing.ID] = true
+			p.categoryIDs[entry.Ref] = existing.ID
+
+			step.action = ActionUnchanged
+			if existing.Archived || existing.Name != entry.Name || existing.Description != entry.Description ||
+				!sameID(existing.ParentID, p.categoryID(entry.ParentRef)) {
+				step.action = ActionUpdate
+			}
+		}
+		p.categories = append(p.categories, step)
+	}
+
+	claimed := make(map[int]bool)
+	for _, entry := range doc.Products {
+		existing, reason := p.index.matchProduct(entry)
+		if reason == "" && existing != nil && claimed[existing.ID] {
+			reason = fmt.Sprintf("another product of the document also matches product id %d", existing.ID)
+		}
+		if reason == "" {
+			reason = p.index.barcodeConflict(entry.Barcodes, existing)
+		}
+		if reason != "" {
+			p.conflict(entry.Ref, entry.Nama, reason)
+			continue
+		}
+
+		step := &productStep{entry: entry, existing: existing, action: ActionCreate}
+		var existingVariants []product.ProductDetail
+		if existing != nil {
+			claimed[existing.ID] = true
+			p.productIDs[entry.Ref] = existing.ID
+			existingVariants = p.index.variants[existing.ID]
+
+			if step.units, err = s.productRepo.GetUnits(existing.ID); err != nil {
+				return nil, err
+			}
+			if step.links, err = s.links(existing.ID, existing.Tipe); err != nil {
+				return nil, err
+			}
+		}
+
+		var conflicts []Conflict
+		step.variants, conflicts = p.index.matchVariants(entry, existingVariants)
+		p.conflicts = append(p.conflicts, conflicts...)
+		for _, v := range step.variants {
+			if v.existing != nil {
+				claimed[v.existing.ID] = true
+				p.productIDs[v.entry.Ref] = v.existing.ID
+			}
+		}
+		if mode == ModeReplace && existing != nil && !existing.Archived {
+			p.staleProducts = append(p.staleProducts, unmatchedVariants(existingVariants, step.variants)...)
+		}
+
+		p.products = append(p.products, step)
+	}
+
+	// Links can point to any product of the document, so they are compared
+	// once every product is matched
+	for _, step := range p.products {
+		if step.existing == nil {
+			continue
+		}
+
+		deletes, creates := p.unitChanges(step)
+		if step.existing.Archived ||
+			len(p.productColumns(step)) > 0 ||
+			!sameBarcodes(step.existing.Barcodes, step.entry.Barcodes) ||
+			len(deletes)+len(creates) > 0 ||
+			!sameLinks(step.links, p.targetLinks(step)) {
+			step.action = ActionUpdate
+		} else {
+			step.action = ActionUnchanged
+		}
+	}
+
+	if mode == ModeReplace {
+		for _, c := range categories {
+			if !c.Archived && !claimedCategories[c.ID] {
+				p.staleCategories = append(p.staleCategories, c)
+			}
+		}
+		for _, prod := range products {
+			if !prod.Archived && !claimed[prod.ID] {
+				p.staleProducts = append(p.staleProducts, prod)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func (p *plan) conflict(ref int, name, reason string) {
+	p.conflicts = append(p.conflicts, Conflict{Ref: ref, Name: name, Reason: reason})
+}
+
+// categoryID maps a category ref of the document to its ID in this store.
+// Categories still to be created have ID 0, which differs from any existing
+// category.
+func (p *plan) categoryID(ref *int) *int {
+	if ref == nil {
+		return nil
+	}
+	id := p.categoryIDs[*ref]
+	return &id
+}
+
+// productColumns returns the columns of the existing product that differ
+// from the entry.
+func (p *plan) productColumns(step *productStep) map[string]interface{} {
+	e, existing := step.entry, step.existing
+	columns := make(map[string]interface{})
+	if existing.Nama != e.Nama {
+		columns["nama"] = e.Nama
+	}
+	if existing.Harga != e.Harga {
+		columns["harga"] = e.Harga
+	}
+	if !sameSKU(existing.SKU, e.SKU) {
+		columns["sku"] = e.SKU
+	}
+	if existing.BaseUnit != e.BaseUnit {
+		columns["base_unit"] = e.BaseUnit
+	}
+	if categoryID := p.categoryID(e.CategoryRef); !sameID(existing.CategoryID, categoryID) {
+		columns["category_id"] = categoryID
+	}
+	return columns
+}
+
+// unitChanges compares units by name. A unit that differs is deleted and
+// created again, and in replace mode units the entry does not list are
+// deleted.
+func (p *plan) unitChanges(step *productStep) ([]int, []UnitEntry) {
+	wanted := make(map[string]UnitEntry)
+	for _, u := range step.entry.Units {
+		wanted[u.Name] = u
+	}
+
+	var deletes []int
+	kept := make(map[string]bool)
+	for _, u := range step.units {
+		want, ok := wanted[u.Name]
+		switch {
+		case ok && want.Conversion == u.Conversion && sameID(want.Harga, u.Harga):
+			kept[u.Name] = true
+		case ok || p.mode == ModeReplace:
+			deletes = append(deletes, u.ID)
+		default:
+			kept[u.Name] = true
+		}
+	}
+
+	var creates []UnitEntry
+	for _, u := range step.entry.Units {
+		if !kept[u.Name] {
+			creates = append(creates, u)
+		}
+	}
+
+	return deletes, creates
+}
+
+// targetLinks returns the components or recipe of the entry with product
+// IDs as refs. Products still to be created have ID 0.
+func (p *plan) targetLinks(step *productStep) []ComponentEntry {
+	links := step.entry.Recipe
+	if step.entry.Tipe == product.TypeBundle {
+		links = step.entry.Components
+	}
+
+	mapped := make([]ComponentEntry, len(links))
+	for i, link := range links {
+		mapped[i] = ComponentEntry{Ref: p.productIDs[link.Ref], Quantity: link.Quantity}
+	}
+	return mapped
+}
+
+func (p *plan) result(dryRun bool) *ImportResult {
+	r := &ImportResult{
+		Mode:       p.mode,
+		DryRun:     dryRun,
+		Applied:    !dryRun && len(p.conflicts) == 0,
+		Categories: make([]ItemResult, 0, len(p.categories)),
+		Products:   make([]ItemResult, 0, len(p.products)),
+		Conflicts:  p.conflicts,
+	}
+
+	for _, step := range p.categories {
+		r.Categories = append(r.Categories, itemResult(&step.entry.Ref, p.categoryIDs[step.entry.Ref], step.entry.Name, step.action))
+	}
+	for _, c := range p.staleCategories {
+		r.Categories = append(r.Categories, itemResult(nil, c.ID, c.Name, ActionArchive))
+	}
+
+	for _, step := range p.products {
+		r.Products = append(r.Products, itemResult(&step.entry.Ref, p.productIDs[step.entry.Ref], step.entry.Nama, step.action))
+		for _, v := range step.variants {
+			name := variantName(step.entry.Nama, v.entry.Options)
+			r.Products = append(r.Products, itemResult(&v.entry.Ref, p.productIDs[v.entry.Ref], name, v.action))
+		}
+	}
+	for _, prod := range p.staleProducts {
+		r.Products = append(r.Products, itemResult(nil, prod.ID, prod.Nama, ActionArchive))
+	}
+
+	return r
+}
+
+func itemResult(ref *int, id int, name, action string) ItemResult {
+	item := ItemResult{Ref: ref, Name: name, Action: action}
+	if id != 0 {
+		item.ID = &id
+	}
+	return item
+}
+
+func newIndex(products []product.ProductDetail, variants map[int][]product.ProductDetail) *index {
+	idx := &index{
+		bySKU:    make(map[string]*product.ProductDetail),
+		byName:   make(map[string][]*product.ProductDetail),
+		barcodes: make(map[string]int),
+		variants: variants,
+	}
+
+	add := func(prod *product.ProductDetail) {
+		if prod.SKU != nil {
+			idx.bySKU[*prod.SKU] = prod
+		}
+		for _, code := range prod.Barcodes {
+			idx.barcodes[code] = prod.ID
+		}
+	}
+
+	for i := range products {
+		prod := &products[i]
+		add(prod)
+		key := nameKey(prod.Nama)
+		idx.byName[key] = append(idx.byName[key], prod)
+
+		for j := range variants[prod.ID] {
+			add(&variants[prod.ID][j])
+		}
+	}
+
+	return idx
+}
+
+// matchProduct finds the existing top-level product of an entry: by SKU,
+// or else by name if the product has no other SKU. The reason is set when
+// the match is ambiguous or the product cannot become the entry.
+func (idx *index) matchProduct(entry ProductEntry) (*product.ProductDetail, string) {
+	var bySKU *product.ProductDetail
+	if entry.SKU != nil {
+		bySKU = idx.bySKU[*entry.SKU]
+	}
+	named := idx.byName[nameKey(entry.Nama)]
+
+	var match *product.ProductDetail
+	if bySKU != nil {
+		if bySKU.ParentID != nil {
+			return nil, fmt.Sprintf("sku %s belongs to variant %s (id %d)", *entry.SKU, bySKU.Nama, bySKU.ID)
+		}
+		if len(named) > 0 && !containsProduct(named, bySKU.ID) {
+			return nil, fmt.Sprintf("sku matches product id %d but nama matches product id %d", bySKU.ID, named[0].ID)
+		}
+		match = bySKU
+	} else {
+		var ok bool
+		if match, ok = pickProduct(named); !ok {
+			return nil, "nama matches more than one product"
+		}
+		if match != nil && match.SKU != nil && entry.SKU != nil {
+			return nil, fmt.Sprintf("nama matches product id %d, which has sku %s", match.ID, *match.SKU)
+		}
+	}
+
+	if match != nil && match.Tipe != entry.Tipe {
+		return nil, fmt.Sprintf("product id %d has tipe %s", match.ID, match.Tipe)
+	}
+
+	return match, ""
+}
+
+// matchVariants pairs the variants of an entry with the existing variants
+// of its product by options. A SKU or barcode that belongs to any other
+// product is a conflict.
+func (idx *index) matchVariants(entry ProductEntry, existing []product.ProductDetail) ([]*variantStep, []Conflict) {
+	byKey := make(map[string]*product.ProductDetail)
+	for i := range existing {
+		byKey[optionsKey(existing[i].Options)] = &existing[i]
+	}
+
+	steps := make([]*variantStep, 0, len(entry.Variants))
+	var conflicts []Conflict
+	for _, v := range entry.Variants {
+		match := byKey[optionsKey(v.Options)]
+
+		var reason string
+		if v.SKU != nil {
+			if owner := idx.bySKU[*v.SKU]; owner != nil && (match == nil || owner.ID != match.ID) {
+				reason = fmt.Sprintf("sku %s belongs to %s (id %d)", *v.SKU, owner.Nama, owner.ID)
+			}
+		}
+		if reason == "" {
+			reason = idx.barcodeConflict(v.Barcodes, match)
+		}
+		if reason != "" {
+			conflicts = append(conflicts, Conflict{Ref: v.Ref, Name: variantName(entry.Nama, v.Options), Reason: reason})
+			continue
+		}
+
+		step := &variantStep{entry: v, existing: match, action: ActionCreate}
+		if match != nil {
+			step.action = ActionUnchanged
+			if match.Harga != v.Harga || !sameSKU(match.SKU, v.SKU) || !sameBarcodes(match.Barcodes, v.Barcodes) {
+				step.action = ActionUpdate
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, conflicts
+}
+
+// barcodeConflict reports a barcode of the entry that is already used by a
+// product other than self, which may be nil.
+func (idx *index) barcodeConflict(codes []string, self *product.ProductDetail) string {
+	for _, code := range codes {
+		if owner, ok := idx.barcodes[code]; ok && (self == nil || owner != self.ID) {
+			return fmt.Sprintf("barcode %s belongs to product id %d", code, owner)
+		}
+	}
+	return ""
+}
+
+func unmatchedVariants(existing []product.ProductDetail, steps []*variantStep) []product.ProductDetail {
+	matched := make(map[int]bool)
+	for _, step := range steps {
+		if step.existing != nil {
+			matched[step.existing.ID] = true
+		}
+	}
+
+	var unmatched []product.ProductDetail
+	for _, v := range existing {
+		if !matched[v.ID] {
+			unmatched = append(unmatched, v)
+		}
+	}
+	return unmatched
+}
+
+// pickCategory chooses among the categories with a name: the only active
+// one, else the only one. It returns false when the name is ambiguous.
+func pickCategory(candidates []*category.Category) (*category.Category, bool) {
+	var active []*category.Category
+	for _, c := range candidates {
+		if !c.Archived {
+			active = append(active, c)
+		}
+	}
+
+	switch {
+	case len(active) == 1:
+		return active[0], true
+	case len(candidates) > 1:
+		return nil, false
+	case len(candidates) == 1:
+		return candidates[0], true
+	}
+	return nil, true
+}
+
+// childrenFirst orders categories so each comes before its parent when
+// both are in the list.
+func childrenFirst(categories []category.Category) []category.Category {
+	parents := make(map[int]*int, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+	depth := func(c category.Category) int {
+		n := 0
+		for id := c.ParentID; id != nil; id = parents[*id] {
+			n++
+		}
+		return n
+	}
+
+	ordered := append([]category.Category(nil), categories...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return depth(ordered[i]) > depth(ordered[j])
+	})
+	return ordered
+}
+
+// pickProduct is pickCategory for products.
+func pickProduct(candidates []*product.ProductDetail) (*product.ProductDetail, bool) {
+	var active []*product.ProductDetail
+	for _, p := range candidates {
+		if !p.Archived {
+			active = append(active, p)
+		}
+	}
+
+	switch {
+	case len(active) == 1:
+		return active[0], true
+	case len(candidates) > 1:
+		return nil, false
+	case len(candidates) == 1:
+		return candidates[0], true
+	}
+	return nil, true
+}
+
+func containsProduct(products []*product.ProductDetail, id int) bool {
+	for _, p := range products {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// variantName builds the name a variant gets when it is created, such as
+// "Kaos Polos (L / Hitam)".
+func variantName(parentName string, options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = options[name]
+	}
+	return parentName + " (" + strings.Join(values, " / ") + ")"
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func sameSKU(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameID(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameBarcodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameLinks(a, b []ComponentEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]ComponentEntry(nil), a...)
+	b = append([]ComponentEntry(nil), b...)
+	sort.Slice(a, func(i, j int) bool { return a[i].Ref < a[j].Ref })
+	sort.Slice(b, func(i, j int) bool { return b[i].Ref < b[j].Ref })
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
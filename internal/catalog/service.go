@@ -0,0 +1,491 @@
+package catalog
+
+import (
+	"belajar-go/internal/category"
+	"belajar-go/internal/product"
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/etag"
+	"belajar-go/pkg/pagination"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Service copies a catalog between stores on top of the category and
+// product repositories. An import is planned and applied in one
+// transaction, so an error halfway rolls back every item saved before it.
+type Service interface {
+	Export() (*Document, error)
+	Import(doc Document, mode string, dryRun bool) (*ImportResult, error)
+}
+
+type service struct {
+	db           *sql.DB
+	categoryRepo category.Repository
+	productRepo  product.Repository
+}
+
+func NewService(db *sql.DB, categoryRepo category.Repository, productRepo product.Repository) Service {
+	return &service{db: db, categoryRepo: categoryRepo, productRepo: productRepo}
+}
+
+// Export returns the active catalog. Archived products are not exported,
+// so bundle components and recipe ingredients that are archived are left
+// out of the products using them.
+func (s *service) Export() (*Document, error) {
+	categories, err := s.allCategories(category.StatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	products, variants, err := s.allProducts(product.StatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: time.Now(),
+		Categories: make([]CategoryEntry, 0, len(categories)),
+		Products:   make([]ProductEntry, 0, len(products)),
+	}
+
+	exportedCategories := make(map[int]bool)
+	for _, c := range categories {
+		exportedCategories[c.ID] = true
+	}
+	for _, c := range categories {
+		entry := CategoryEntry{Ref: c.ID, Name: c.Name, Description: c.Description}
+		// A category under an archived parent is exported at the top level
+		if c.ParentID != nil && exportedCategories[*c.ParentID] {
+			entry.ParentRef = c.ParentID
+		}
+		doc.Categories = append(doc.Categories, entry)
+	}
+
+	exported := make(map[int]bool)
+	for _, p := range products {
+		exported[p.ID] = true
+		for _, v := range variants[p.ID] {
+			exported[v.ID] = true
+		}
+	}
+
+	for _, p := range products {
+		entry := ProductEntry{
+			Ref:      p.ID,
+			Nama:     p.Nama,
+			Harga:    p.Harga,
+			Tipe:     p.Tipe,
+			SKU:      p.SKU,
+			Barcodes: p.Barcodes,
+			BaseUnit: p.BaseUnit,
+		}
+		if p.CategoryID != nil && exportedCategories[*p.CategoryID] {
+			entry.CategoryRef = p.CategoryID
+		}
+
+		units, err := s.productRepo.GetUnits(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range units {
+			entry.Units = append(entry.Units, UnitEntry{Name: u.Name, Conversion: u.Conversion, Harga: u.Harga})
+		}
+
+		links, err := s.links(p.ID, p.Tipe)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if !exported[link.Ref] {
+				continue
+			}
+			if p.Tipe == product.TypeBundle {
+				entry.Components = append(entry.Components, link)
+			} else {
+				entry.Recipe = append(entry.Recipe, link)
+			}
+		}
+
+		for _, v := range variants[p.ID] {
+			entry.Variants = append(entry.Variants, VariantEntry{
+				Ref:      v.ID,
+				Harga:    v.Harga,
+				SKU:      v.SKU,
+				Barcodes: v.Barcodes,
+				Options:  v.Options,
+			})
+		}
+
+		doc.Products = append(doc.Products, entry)
+	}
+
+	return doc, nil
+}
+
+// Import matches the document against this store and, unless dryRun or
+// there are conflicts, applies it. In replace mode the active categories,
+// products and variants the document does not list are archived.
+//
+// Every item is saved with the version the plan read, so an item another
+// request changes in between is a conflict instead of being overwritten.
+func (s *service) Import(doc Document, mode string, dryRun bool) (*ImportResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txs := &service{db: s.db, categoryRepo: s.categoryRepo.WithTx(tx), productRepo: s.productRepo.WithTx(tx)}
+	p, err := txs.plan(doc, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.conflicts) > 0 || dryRun {
+		return p.result(dryRun), nil
+	}
+
+	if err := txs.apply(p); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return p.result(dryRun), nil
+}
+
+// apply saves the plan in dependency order: categories, parents first, then
+// products with their variants and units, then bundle components and
+// recipes, which can point to any product, and last the archiving of a
+// replace.
+func (s *service) apply(p *plan) error {
+	for _, step := range p.categories {
+		if err := s.applyCategory(p, step); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range p.products {
+		if err := s.applyProduct(p, step); err != nil {
+			return err
+		}
+	}
+
+	if err := s.applyLinks(p); err != nil {
+		return err
+	}
+
+	for _, prod := range p.staleProducts {
+		if err := s.productRepo.Archive(prod.ID, etag.Version(prod.Version)); err != nil {
+			return changed(err, "product %q", prod.Nama)
+		}
+	}
+	// Subcategories go first, so archiving a parent finds none left active.
+	// Products of the document were moved out already; any others are
+	// archived by the replace too
+	for _, c := range childrenFirst(p.staleCategories) {
+		if err := s.categoryRepo.Archive(c.ID, etag.Version(c.Version), category.ArchiveOptions{Force: true}); err != nil {
+			return changed(err, "category %q", c.Name)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) applyCategory(p *plan, step *categoryStep) error {
+	e := step.entry
+	switch step.action {
+	case ActionCreate:
+		req := category.CreateCategoryRequest{Name: e.Name, Description: e.Description, ParentID: p.categoryID(e.ParentRef)}
+		c, err := s.categoryRepo.Create(req)
+		if err != nil {
+			return err
+		}
+		p.categoryIDs[e.Ref] = c.ID
+	case ActionUpdate:
+		// A patch keeps the color, icon and position, which are not part of
+		// the catalog. It goes before a restore, which moves the version.
+		columns := map[string]interface{}{
+			"name":        e.Name,
+			"description": e.Description,
+			"parent_id":   p.categoryID(e.ParentRef),
+		}
+		if _, err := s.categoryRepo.Patch(step.existing.ID, columns, etag.Version(step.existing.Version)); err != nil {
+			return changed(err, "category %q", e.Name)
+		}
+		if step.existing.Archived {
+			if _, err := s.categoryRepo.Restore(step.existing.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *service) applyProduct(p *plan, step *productStep) error {
+	e := step.entry
+	switch step.action {
+	case ActionCreate:
+		prod, err := s.productRepo.Create(product.CreateProductRequest{
+			Nama:       e.Nama,
+			Harga:      e.Harga,
+			Tipe:       e.Tipe,
+			SKU:        e.SKU,
+			Barcodes:   e.Barcodes,
+			BaseUnit:   e.BaseUnit,
+			CategoryID: p.categoryID(e.CategoryRef),
+		})
+		if err != nil {
+			return err
+		}
+		p.productIDs[e.Ref] = prod.ID
+	case ActionUpdate:
+		// Without columns or barcodes the patch only checks the version,
+		// which a restore would move
+		pp := product.ProductPatch{Columns: p.productColumns(step)}
+		if !sameBarcodes(step.existing.Barcodes, e.Barcodes) {
+			barcodes := e.Barcodes
+			pp.Barcodes = &barcodes
+		}
+		if _, err := s.productRepo.Patch(step.existing.ID, pp, etag.Version(step.existing.Version)); err != nil {
+			return changed(err, "product %q", e.Nama)
+		}
+
+		if step.existing.Archived {
+			if err := s.restoreProduct(p, step); err != nil {
+				return err
+			}
+		}
+	}
+
+	id := p.productIDs[e.Ref]
+	deletes, creates := p.unitChanges(step)
+	for _, unitID := range deletes {
+		if err := s.productRepo.DeleteUnit(id, unitID); err != nil {
+			return err
+		}
+	}
+	for _, u := range creates {
+		if _, err := s.productRepo.CreateUnit(id, product.CreateUnitRequest{Name: u.Name, Conversion: u.Conversion, Harga: u.Harga}); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range step.variants {
+		if err := s.applyVariant(p, id, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreProduct restores an archived product. Its variants come back with
+// it and were not visible when the plan was made, so they are matched again.
+func (s *service) restoreProduct(p *plan, step *productStep) error {
+	if err := s.productRepo.Restore(step.existing.ID); err != nil {
+		return err
+	}
+
+	variants, err := s.productRepo.GetVariants([]int{step.existing.ID})
+	if err != nil {
+		return err
+	}
+
+	steps, conflicts := p.index.matchVariants(step.entry, variants)
+	if len(conflicts) > 0 {
+		return apperror.Conflict("%s: %s", conflicts[0].Name, conflicts[0].Reason)
+	}
+
+	step.variants = steps
+	for _, v := range steps {
+		if v.existing != nil {
+			p.productIDs[v.entry.Ref] = v.existing.ID
+		}
+	}
+	if p.mode == ModeReplace {
+		p.staleProducts = append(p.staleProducts, unmatchedVariants(variants, steps)...)
+	}
+
+	return nil
+}
+
+func (s *service) applyVariant(p *plan, parentID int, step *variantStep) error {
+	e := step.entry
+	switch step.action {
+	case ActionCreate:
+		prod, err := s.productRepo.CreateVariant(parentID, product.CreateVariantRequest{
+			Harga:    e.Harga,
+			SKU:      e.SKU,
+			Barcodes: e.Barcodes,
+			Options:  e.Options,
+		})
+		if err != nil {
+			return err
+		}
+		p.productIDs[e.Ref] = prod.ID
+	case ActionUpdate:
+		pp := product.ProductPatch{Columns: make(map[string]interface{})}
+		if step.existing.Harga != e.Harga {
+			pp.Columns["harga"] = e.Harga
+		}
+		if !sameSKU(step.existing.SKU, e.SKU) {
+			pp.Columns["sku"] = e.SKU
+		}
+		if !sameBarcodes(step.existing.Barcodes, e.Barcodes) {
+			barcodes := e.Barcodes
+			pp.Barcodes = &barcodes
+		}
+		if _, err := s.productRepo.Patch(step.existing.ID, pp, etag.Version(step.existing.Version)); err != nil {
+			return changed(err, "variant %q", step.existing.Nama)
+		}
+	}
+	return nil
+}
+
+// changed turns a version mismatch on an item of the plan into a conflict:
+// another request changed the item after the plan read it.
+func changed(err error, format string, args ...interface{}) error {
+	if errors.Is(err, etag.ErrPreconditionFailed) {
+		return apperror.Conflict(format+" was changed by another request, try the import again", args...)
+	}
+	return err
+}
+
+// applyLinks saves the bundle components and recipes that changed. They are
+// all cleared before any is set, since a product cannot get a recipe while
+// it is a bundle component or be an ingredient while it has a recipe.
+func (s *service) applyLinks(p *plan) error {
+	var changed []*productStep
+	for _, step := range p.products {
+		if !sameLinks(step.links, p.targetLinks(step)) {
+			changed = append(changed, step)
+		}
+	}
+
+	for _, step := range changed {
+		if len(step.links) == 0 {
+			continue
+		}
+		if err := s.setLinks(p.productIDs[step.entry.Ref], step.entry.Tipe, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range changed {
+		links := p.targetLinks(step)
+		if len(links) == 0 {
+			continue
+		}
+		if err := s.setLinks(p.productIDs[step.entry.Ref], step.entry.Tipe, links); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// links returns the bundle components or recipe ingredients of a product,
+// with their product IDs as refs.
+func (s *service) links(id int, tipe string) ([]ComponentEntry, error) {
+	links := make([]ComponentEntry, 0)
+	switch tipe {
+	case product.TypeBundle:
+		components, err := s.productRepo.GetComponents(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range components {
+			links = append(links, ComponentEntry{Ref: c.ComponentID, Quantity: c.Quantity})
+		}
+	case product.TypeStandard:
+		recipe, err := s.productRepo.GetRecipe(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range recipe {
+			links = append(links, ComponentEntry{Ref: item.IngredientID, Quantity: item.Quantity})
+		}
+	}
+	return links, nil
+}
+
+// setLinks replaces the bundle components or the recipe of a product. The
+// refs of links are product IDs.
+func (s *service) setLinks(id int, tipe string, links []ComponentEntry) error {
+	if tipe == product.TypeBundle {
+		components := make([]product.BundleComponent, len(links))
+		for i, link := range links {
+			components[i] = product.BundleComponent{ComponentID: link.Ref, Quantity: link.Quantity}
+		}
+		_, err := s.productRepo.ReplaceComponents(id, components)
+		return err
+	}
+
+	items := make([]product.RecipeItem, len(links))
+	for i, link := range links {
+		items[i] = product.RecipeItem{IngredientID: link.Ref, Quantity: link.Quantity}
+	}
+	_, err := s.productRepo.ReplaceRecipe(id, items)
+	return err
+}
+
+// allCategories pages through every category with the given status.
+func (s *service) allCategories(status string) ([]category.Category, error) {
+	var all []category.Category
+	for page := 1; ; page++ {
+		categories, total, err := s.categoryRepo.GetAll(category.CategoryFilter{
+			Status: status,
+			Params: pagination.Params{Page: page, PerPage: pagination.MaxPerPage, Sort: "id"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, categories...)
+		if len(categories) == 0 || len(all) >= total {
+			return all, nil
+		}
+	}
+}
+
+// allProducts pages through every top-level product with the given status
+// and loads their active variants, keyed by parent ID.
+func (s *service) allProducts(status string) ([]product.ProductDetail, map[int][]product.ProductDetail, error) {
+	var all []product.ProductDetail
+	for page := 1; ; page++ {
+		products, total, err := s.productRepo.GetAll(product.ProductFilter{
+			Status: status,
+			Params: pagination.Params{Page: page, PerPage: pagination.MaxPerPage, Sort: "id"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		all = append(all, products...)
+		if len(products) == 0 || len(all) >= total {
+			break
+		}
+	}
+
+	ids := make([]int, len(all))
+	for i, p := range all {
+		ids[i] = p.ID
+	}
+
+	found, err := s.productRepo.GetVariants(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variants := make(map[int][]product.ProductDetail)
+	for _, v := range found {
+		variants[*v.ParentID] = append(variants[*v.ParentID], v)
+	}
+
+	return all, variants, nil
+}
@@ -1,21 +1,132 @@
 package category
 
-import "time"
+import (
+	"belajar-go/pkg/pagination"
+	"belajar-go/pkg/validation"
+	"time"
+)
+
+// MaxIconLength matches the icon column. Icons are names from the icon set
+// of the POS, such as "coffee".
+const MaxIconLength = 50
+
+// Listing filters on the archive state of categories.
+const (
+	StatusActive   = "active"
+	StatusArchived = "archived"
+	StatusAll      = "all"
+)
 
 type Category struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	ParentID     *int       `json:"parent_id"`
+	Color        *string    `json:"color"`
+	Icon         *string    `json:"icon"`
+	Position     *int       `json:"position"`
+	ProductCount int        `json:"product_count"`
+	Archived     bool       `json:"archived"`
+	ArchivedAt   *time.Time `json:"archived_at"`
+	Version      int        `json:"version"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// scan reads the columns listed in categoryColumns.
+func (c *Category) scan(row interface{ Scan(...interface{}) error }) error {
+	if err := row.Scan(&c.ID, &c.Name, &c.Description, &c.ParentID, &c.Color, &c.Icon, &c.Position, &c.ArchivedAt, &c.Version, &c.CreatedAt, &c.UpdatedAt, &c.ProductCount); err != nil {
+		return err
+	}
+
+	c.Archived = c.ArchivedAt != nil
+	return nil
+}
+
+// CategoryNode is a category with its subcategories, as returned by
+// GET /categories/tree.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
 }
 
 type CreateCategoryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	ParentID    *int    `json:"parent_id"`
+	Color       *string `json:"color"`
+	Icon        *string `json:"icon"`
+}
+
+func (req CreateCategoryRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	if req.ParentID != nil {
+		v.Min("parent_id", *req.ParentID, 1)
+	}
+	validateDisplay(&v, req.Color, req.Icon)
+	return v.Errors()
 }
 
+// UpdateCategoryRequest replaces a category, so a missing parent_id moves
+// it to the top level.
 type UpdateCategoryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	ParentID    *int    `json:"parent_id"`
+	Color       *string `json:"color"`
+	Icon        *string `json:"icon"`
+}
+
+func (req UpdateCategoryRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	if req.ParentID != nil {
+		v.Min("parent_id", *req.ParentID, 1)
+	}
+	validateDisplay(&v, req.Color, req.Icon)
+	return v.Errors()
+}
+
+// ArchiveOptions says what happens to the active products of a category
+// being archived: they are moved to ReassignTo, or with Force left in the
+// archived category. Without either, archiving such a category fails. Force
+// also archives its active subcategories, which otherwise make it fail.
+type ArchiveOptions struct {
+	ReassignTo *int
+	Force      bool
+}
+
+// MoveCategoryRequest moves a category, with its subcategories, under
+// another category or, with a null parent_id, to the top level.
+type MoveCategoryRequest struct {
+	ParentID *int `json:"parent_id"`
+}
+
+func (req MoveCategoryRequest) Validate() validation.Errors {
+	var v validation.Validator
+	if req.ParentID != nil {
+		v.Min("parent_id", *req.ParentID, 1)
+	}
+	return v.Errors()
+}
+
+// CategoryFilter narrows GET /categories. Nil fields are not filtered on and
+// Status defaults to active, hiding archived categories.
+type CategoryFilter struct {
+	Status       string
+	Name         string
+	UpdatedSince *time.Time
+	pagination.Params
+}
+
+// validateDisplay checks the optional color and icon shown on the POS.
+func validateDisplay(v *validation.Validator, color, icon *string) {
+	if color != nil {
+		v.Color("color", *color)
+	}
+	if icon != nil {
+		v.Required("icon", *icon)
+		v.MaxLength("icon", *icon, MaxIconLength)
+	}
 }
@@ -1,10 +1,15 @@
 package category
 
 import (
+	"belajar-go/pkg/etag"
+	"belajar-go/pkg/pagination"
+	"belajar-go/pkg/patch"
 	"belajar-go/pkg/response"
+	"belajar-go/pkg/validation"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type Handler struct {
@@ -16,13 +21,79 @@ func NewHandler(service Service) *Handler {
 }
 
 func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAll()
+	q := r.URL.Query()
+
+	params, err := pagination.Parse(q, categorySortColumns, "id")
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := CategoryFilter{Status: q.Get("status"), Name: q.Get("name"), Params: params}
+	switch filter.Status {
+	case "", StatusActive, StatusArchived, StatusAll:
+	default:
+		response.Error(w, http.StatusBadRequest, "Invalid status, use active, archived or all")
+		return
+	}
+	if v := q.Get("updated_since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			since, err = time.ParseInLocation("2006-01-02", v, time.Local)
+		}
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid updated_since, use RFC3339 or YYYY-MM-DD")
+			return
+		}
+		filter.UpdatedSince = &since
+	}
+
+	categories, total, err := h.service.GetAll(filter)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	meta := params.Meta(total)
+	tag, err := etag.Hash([]interface{}{categories, meta})
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+	if etag.NotModified(w, r, tag) {
+		return
+	}
+
+	response.Paginated(w, http.StatusOK, categories, meta)
+}
+
+// GetTree returns the categories nested under their parents. Like GetAll it
+// lists active categories unless ?status says otherwise.
+func (h *Handler) GetTree(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", StatusActive, StatusArchived, StatusAll:
+	default:
+		response.Error(w, http.StatusBadRequest, "Invalid status, use active, archived or all")
+		return
+	}
+
+	tree, err := h.service.GetTree(status)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	tag, err := etag.Hash(tree)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+	if etag.NotModified(w, r, tag) {
 		return
 	}
 
-	response.Success(w, http.StatusOK, categories)
+	response.Success(w, http.StatusOK, tree)
 }
 
 func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +106,11 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	category, err := h.service.GetByID(id)
 	if err != nil {
-		response.Error(w, http.StatusNotFound, err.Error())
+		response.FromError(w, err)
+		return
+	}
+
+	if etag.NotModified(w, r, etag.Version(category.Version)) {
 		return
 	}
 
@@ -49,9 +124,14 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
 	category, err := h.service.Create(req)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.FromError(w, err)
 		return
 	}
 
@@ -66,21 +146,152 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifMatch, ok := etag.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req UpdateCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	category, err := h.service.Update(id, req)
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	category, err := h.service.Update(id, req, ifMatch)
 	if err != nil {
-		response.Error(w, http.StatusNotFound, err.Error())
+		response.FromError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.Version(category.Version))
 	response.Success(w, http.StatusOK, category)
 }
 
+// Patch applies a JSON Merge Patch: omitted fields are left untouched, null
+// clears the description, color or icon and a null parent_id moves the
+// category to the top level.
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	ifMatch, ok := etag.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var doc patch.Document
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	columns := make(map[string]interface{})
+	var v validation.Validator
+	for key := range doc {
+		var s string
+		switch key {
+		case "name":
+			if _, err := doc.Decode(key, &s, false); err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			v.Required(key, s)
+			columns[key] = s
+		case "description", "color", "icon":
+			isNull, err := doc.Decode(key, &s, true)
+			if err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			if isNull {
+				columns[key] = nil
+				continue
+			}
+			switch key {
+			case "color":
+				v.Color(key, s)
+			case "icon":
+				v.Required(key, s)
+				v.MaxLength(key, s, MaxIconLength)
+			}
+			columns[key] = s
+		case "parent_id":
+			// Kept as *int so the repository knows the parent changes
+			var parentID *int
+			if _, err := doc.Decode(key, &parentID, true); err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			if parentID != nil {
+				v.Min(key, *parentID, 1)
+			}
+			columns[key] = parentID
+		default:
+			v.Add(key, validation.CodeInvalid, "unknown field "+key)
+		}
+	}
+	if errs := v.Errors(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	category, err := h.service.Patch(id, columns, ifMatch)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.Version(category.Version))
+	response.Success(w, http.StatusOK, category)
+}
+
+// Move puts a category, with its subcategories, under another category.
+func (h *Handler) Move(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	ifMatch, ok := etag.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req MoveCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	category, err := h.service.Move(id, req.ParentID, ifMatch)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.Version(category.Version))
+	response.Success(w, http.StatusOK, category)
+}
+
+// Delete archives a category. One that still has products needs
+// ?reassign_to={id} to move them first, or ?force=true to keep them in the
+// archived category.
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.Atoi(idStr)
@@ -89,10 +300,54 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(id); err != nil {
-		response.Error(w, http.StatusNotFound, err.Error())
+	var opts ArchiveOptions
+	q := r.URL.Query()
+	if v := q.Get("reassign_to"); v != "" {
+		to, err := strconv.Atoi(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid reassign_to")
+			return
+		}
+		opts.ReassignTo = &to
+	}
+	if v := q.Get("force"); v != "" {
+		if opts.Force, err = strconv.ParseBool(v); err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid force")
+			return
+		}
+	}
+	if opts.ReassignTo != nil && opts.Force {
+		response.Error(w, http.StatusBadRequest, "Use either reassign_to or force, not both")
+		return
+	}
+
+	ifMatch, ok := etag.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err = h.service.Archive(id, ifMatch, opts)
+	if err != nil {
+		response.FromError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	category, err := h.service.Restore(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, category)
+}
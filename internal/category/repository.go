@@ -1,54 +1,164 @@
 package category
 
 import (
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/database"
+	"belajar-go/pkg/etag"
+	"belajar-go/pkg/patch"
+	"belajar-go/pkg/validation"
 	"database/sql"
 	"fmt"
+
+	"github.com/lib/pq"
 )
 
 type Repository interface {
-	GetAll() ([]Category, error)
+	GetAll(filter CategoryFilter) ([]Category, int, error)
+	GetTree(status string) ([]Category, error)
 	GetByID(id int) (*Category, error)
 	Create(req CreateCategoryRequest) (*Category, error)
-	Update(id int, req UpdateCategoryRequest) (*Category, error)
-	Delete(id int) error
+	Update(id int, req UpdateCategoryRequest, ifMatch string) (*Category, error)
+	Patch(id int, columns map[string]interface{}, ifMatch string) (*Category, error)
+	Move(id int, parentID *int, ifMatch string) (*Category, error)
+	Archive(id int, ifMatch string, opts ArchiveOptions) error
+	Restore(id int) (*Category, error)
+	WithTx(tx *sql.Tx) Repository
+}
+
+// categoryColumns ends with the product count, which leaves out variants
+// and archived products like GET /products does.
+const categoryColumns = `id, name, COALESCE(description, ''), parent_id, color, icon, position, archived_at, version, created_at, updated_at,
+	(SELECT COUNT(*) FROM products p WHERE p.category_id = categories.id AND p.parent_id IS NULL AND p.archived_at IS NULL)`
+
+// SubtreeIDs selects the IDs of a category and all of its subcategories. It
+// takes the category ID as a %d placeholder number, for filters such as
+// fmt.Sprintf("category_id IN ("+SubtreeIDs+")", n).
+const SubtreeIDs = `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = $%d
+		UNION
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	) SELECT id FROM subtree`
+
+// AncestorIDs selects the IDs of a category and all of its parents, the
+// categories whose SubtreeIDs contain it. It takes the same placeholder.
+const AncestorIDs = `WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id FROM categories WHERE id = $%d
+		UNION
+		SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+	) SELECT id FROM ancestors`
+
+// treeLock is the advisory lock key held while a parent changes. Checking
+// for cycles and moving must not interleave, or two concurrent moves could
+// each pass the check and form a cycle together.
+const treeLock = 4801
+
+// categorySortColumns whitelists the sort keys accepted by GET /categories.
+var categorySortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"position":   "position",
 }
 
 type repository struct {
-	db *sql.DB
+	db database.Conn
 }
 
 func NewRepository(db *sql.DB) Repository {
-	return &repository{db: db}
+	return &repository{db: database.NewConn(db)}
+}
+
+func (r *repository) WithTx(tx *sql.Tx) Repository {
+	return &repository{db: r.db.WithTx(tx)}
 }
 
-func (r *repository) GetAll() ([]Category, error) {
-	query := `SELECT id, name, description, created_at, updated_at FROM categories ORDER BY id ASC`
+func (r *repository) GetAll(filter CategoryFilter) ([]Category, int, error) {
+	where := " WHERE archived_at IS NULL"
+	switch filter.Status {
+	case StatusArchived:
+		where = " WHERE archived_at IS NOT NULL"
+	case StatusAll:
+		where = " WHERE TRUE"
+	}
+	args := []interface{}{}
+
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		where += fmt.Sprintf(" AND name ILIKE $%d", len(args))
+	}
+	if filter.UpdatedSince != nil {
+		args = append(args, *filter.UpdatedSince)
+		where += fmt.Sprintf(" AND updated_at >= $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM categories"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + categoryColumns + ` FROM categories` +
+		where + filter.OrderBy(categorySortColumns, "id") +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, filter.PerPage, filter.Offset())
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	categories := make([]Category, 0)
+	for rows.Next() {
+		var cat Category
+		if err := cat.scan(rows); err != nil {
+			return nil, 0, err
+		}
+		categories = append(categories, cat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return categories, total, nil
+}
+
+// GetTree returns every category with the given status, ordered by position
+// and name so siblings are sorted once the tree is built.
+func (r *repository) GetTree(status string) ([]Category, error) {
+	where := " WHERE archived_at IS NULL"
+	switch status {
+	case StatusArchived:
+		where = " WHERE archived_at IS NOT NULL"
+	case StatusAll:
+		where = ""
+	}
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(`SELECT ` + categoryColumns + ` FROM categories` + where + ` ORDER BY position, name, id`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var categories []Category
+	categories := make([]Category, 0)
 	for rows.Next() {
 		var cat Category
-		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Description, &cat.CreatedAt, &cat.UpdatedAt); err != nil {
+		if err := cat.scan(rows); err != nil {
 			return nil, err
 		}
 		categories = append(categories, cat)
 	}
 
-	return categories, nil
+	return categories, rows.Err()
 }
 
 func (r *repository) GetByID(id int) (*Category, error) {
-	query := `SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1`
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = $1`
 
 	var cat Category
-	err := r.db.QueryRow(query, id).Scan(&cat.ID, &cat.Name, &cat.Description, &cat.CreatedAt, &cat.UpdatedAt)
+	err := cat.scan(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("category not found")
+		return nil, apperror.NotFound("category not found")
 	}
 	if err != nil {
 		return nil, err
@@ -58,52 +168,239 @@ func (r *repository) GetByID(id int) (*Category, error) {
 }
 
 func (r *repository) Create(req CreateCategoryRequest) (*Category, error) {
-	query := `INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id, name, description, created_at, updated_at`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	if err := checkParent(tx, 0, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO categories (name, description, parent_id, color, icon) VALUES ($1, $2, $3, $4, $5) RETURNING ` + categoryColumns
 
 	var cat Category
-	err := r.db.QueryRow(query, req.Name, req.Description).Scan(
-		&cat.ID, &cat.Name, &cat.Description, &cat.CreatedAt, &cat.UpdatedAt,
-	)
-	if err != nil {
+	if err := cat.scan(tx.QueryRow(query, req.Name, req.Description, req.ParentID, req.Color, req.Icon)); err != nil {
+		return nil, ConstraintError(err)
+	}
+
+	if err := r.db.Commit(tx); err != nil {
 		return nil, err
 	}
 
 	return &cat, nil
 }
 
-func (r *repository) Update(id int, req UpdateCategoryRequest) (*Category, error) {
-	query := `UPDATE categories SET name = $1, description = $2 WHERE id = $3 RETURNING id, name, description, created_at, updated_at`
+func (r *repository) Update(id int, req UpdateCategoryRequest, ifMatch string) (*Category, error) {
+	query := `UPDATE categories SET name = $1, description = $2, parent_id = $3, color = $4, icon = $5 WHERE id = $6 RETURNING ` + categoryColumns
 
-	var cat Category
-	err := r.db.QueryRow(query, req.Name, req.Description, id).Scan(
-		&cat.ID, &cat.Name, &cat.Description, &cat.CreatedAt, &cat.UpdatedAt,
-	)
+	check := func(tx *sql.Tx) error { return checkParent(tx, id, req.ParentID) }
+	return r.updateVersioned(id, ifMatch, check, query, req.Name, req.Description, req.ParentID, req.Color, req.Icon, id)
+}
+
+// Patch updates only the given columns; a nil value sets the column to NULL.
+func (r *repository) Patch(id int, columns map[string]interface{}, ifMatch string) (*Category, error) {
+	if len(columns) == 0 {
+		return r.updateVersioned(id, ifMatch, nil, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id)
+	}
+
+	var check func(tx *sql.Tx) error
+	if parentID, ok := columns["parent_id"].(*int); ok {
+		check = func(tx *sql.Tx) error { return checkParent(tx, id, parentID) }
+	}
+	sets, args := patch.Assignments(columns)
+	query := fmt.Sprintf("UPDATE categories SET %s WHERE id = $%d RETURNING ", sets, len(args)+1) + categoryColumns
+	args = append(args, id)
+
+	return r.updateVersioned(id, ifMatch, check, query, args...)
+}
+
+// Move changes the parent of a category. Its subcategories move with it,
+// since they keep pointing to it.
+func (r *repository) Move(id int, parentID *int, ifMatch string) (*Category, error) {
+	query := `UPDATE categories SET parent_id = $1 WHERE id = $2 RETURNING ` + categoryColumns
+
+	check := func(tx *sql.Tx) error { return checkParent(tx, id, parentID) }
+	return r.updateVersioned(id, ifMatch, check, query, parentID, id)
+}
+
+// Archive hides a category from listings. A category with active products
+// is only archived when opts moves them to another category, or with Force,
+// where they keep the archived category. A category with active
+// subcategories is only archived with Force, which archives them too.
+func (r *repository) Archive(id int, ifMatch string, opts ArchiveOptions) error {
+	query := `UPDATE categories SET archived_at = COALESCE(archived_at, NOW()) WHERE id = $1 RETURNING ` + categoryColumns
+
+	check := func(tx *sql.Tx) error {
+		if err := archiveSubcategories(tx, id, opts.Force); err != nil {
+			return err
+		}
+		if opts.ReassignTo != nil {
+			return reassignProducts(tx, id, *opts.ReassignTo)
+		}
+		if opts.Force {
+			return nil
+		}
+
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE category_id = $1 AND parent_id IS NULL AND archived_at IS NULL", id).
+			Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return apperror.Conflict("category still has %d products, move them with reassign_to or use force=true", count)
+		}
+		return nil
+	}
+
+	_, err := r.updateVersioned(id, ifMatch, check, query, id)
+	return err
+}
+
+// archiveSubcategories archives the active subcategories of category id, at
+// any depth, or without force refuses when there are any, since they would
+// show up at the top level of the tree.
+func archiveSubcategories(tx *sql.Tx, id int, force bool) error {
+	if !force {
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND archived_at IS NULL", id).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return apperror.Conflict("category still has %d subcategories, archive or move them first or use force=true", count)
+		}
+		return nil
+	}
+
+	_, err := tx.Exec(`UPDATE categories SET archived_at = NOW() WHERE id IN (`+fmt.Sprintf(SubtreeIDs, 1)+`) AND id <> $1 AND archived_at IS NULL`, id)
+	return err
+}
+
+// reassignProducts moves every product of category id, archived ones and
+// variants included, to category to, which must be another active category.
+func reassignProducts(tx *sql.Tx, id, to int) error {
+	if to == id {
+		return validation.Field("reassign_to", validation.CodeInvalid, "reassign_to must be another category")
+	}
+
+	var archived bool
+	err := tx.QueryRow("SELECT archived_at IS NOT NULL FROM categories WHERE id = $1", to).Scan(&archived)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("category not found")
+		return validation.Field("reassign_to", validation.CodeNotFound, "category to reassign to not found")
 	}
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if archived {
+		return validation.Field("reassign_to", validation.CodeInvalid, "category to reassign to is archived")
 	}
 
-	return &cat, nil
+	_, err = tx.Exec("UPDATE products SET category_id = $1 WHERE category_id = $2", to, id)
+	return err
 }
 
-func (r *repository) Delete(id int) error {
-	query := `DELETE FROM categories WHERE id = $1`
+// checkParent makes sure parentID can be the parent of category id, which
+// is 0 for a new category: it must be an active category and neither the
+// category itself nor one of its descendants, which would make a cycle.
+func checkParent(tx *sql.Tx, id int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
 
-	result, err := r.db.Exec(query, id)
-	if err != nil {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", treeLock); err != nil {
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	var archived, cycle bool
+	err := tx.QueryRow(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT archived_at IS NOT NULL, EXISTS(SELECT 1 FROM ancestors WHERE id = $2)
+		FROM categories
+		WHERE id = $1`, *parentID, id).Scan(&archived, &cycle)
+	if err == sql.ErrNoRows {
+		return validation.Field("parent_id", validation.CodeNotFound, "parent category not found")
+	}
 	if err != nil {
 		return err
 	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("category not found")
+	if archived {
+		return validation.Field("parent_id", validation.CodeInvalid, "parent category is archived")
+	}
+	if cycle {
+		return validation.Field("parent_id", validation.CodeInvalid, "a category cannot be moved under itself or one of its subcategories")
 	}
 
 	return nil
 }
+
+// updateVersioned locks the category, checks the If-Match tag against its
+// version, runs check if given and then query, which must return
+// categoryColumns.
+func (r *repository) updateVersioned(id int, ifMatch string, check func(tx *sql.Tx) error, query string, args ...interface{}) (*Category, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	var version int
+	err = tx.QueryRow("SELECT version FROM categories WHERE id = $1 FOR UPDATE", id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !etag.MatchesStrong(ifMatch, etag.Version(version)) {
+		return nil, etag.ErrPreconditionFailed
+	}
+
+	if check != nil {
+		if err := check(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	var cat Category
+	if err := cat.scan(tx.QueryRow(query, args...)); err != nil {
+		return nil, ConstraintError(err)
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	return &cat, nil
+}
+
+func (r *repository) Restore(id int) (*Category, error) {
+	query := `UPDATE categories SET archived_at = NULL WHERE id = $1 RETURNING ` + categoryColumns
+
+	var cat Category
+	err := cat.scan(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &cat, nil
+}
+
+// ConstraintError turns a duplicate name into a conflict. Names are unique
+// regardless of case, archived categories included.
+func ConstraintError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "categories_name_lower_key" {
+		return apperror.Conflict("a category with this name already exists")
+	}
+	return err
+}
@@ -1,11 +1,15 @@
 package category
 
 type Service interface {
-	GetAll() ([]Category, error)
+	GetAll(filter CategoryFilter) ([]Category, int, error)
+	GetTree(status string) ([]*CategoryNode, error)
 	GetByID(id int) (*Category, error)
 	Create(req CreateCategoryRequest) (*Category, error)
-	Update(id int, req UpdateCategoryRequest) (*Category, error)
-	Delete(id int) error
+	Update(id int, req UpdateCategoryRequest, ifMatch string) (*Category, error)
+	Patch(id int, columns map[string]interface{}, ifMatch string) (*Category, error)
+	Move(id int, parentID *int, ifMatch string) (*Category, error)
+	Archive(id int, ifMatch string, opts ArchiveOptions) error
+	Restore(id int) (*Category, error)
 }
 
 type service struct {
@@ -16,8 +20,37 @@ func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) GetAll() ([]Category, error) {
-	return s.repo.GetAll()
+func (s *service) GetAll(filter CategoryFilter) ([]Category, int, error) {
+	return s.repo.GetAll(filter)
+}
+
+// GetTree nests the categories under their parents. A category whose parent
+// is not in the tree, such as one under an archived parent when listing
+// active categories, is shown at the top level.
+func (s *service) GetTree(status string) ([]*CategoryNode, error) {
+	categories, err := s.repo.GetTree(status)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int]*CategoryNode, len(categories))
+	for _, cat := range categories {
+		nodes[cat.ID] = &CategoryNode{Category: cat, Children: make([]*CategoryNode, 0)}
+	}
+
+	roots := make([]*CategoryNode, 0)
+	for _, cat := range categories {
+		node := nodes[cat.ID]
+		if cat.ParentID != nil {
+			if parent, ok := nodes[*cat.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return roots, nil
 }
 
 func (s *service) GetByID(id int) (*Category, error) {
@@ -28,10 +61,22 @@ func (s *service) Create(req CreateCategoryRequest) (*Category, error) {
 	return s.repo.Create(req)
 }
 
-func (s *service) Update(id int, req UpdateCategoryRequest) (*Category, error) {
-	return s.repo.Update(id, req)
+func (s *service) Update(id int, req UpdateCategoryRequest, ifMatch string) (*Category, error) {
+	return s.repo.Update(id, req, ifMatch)
+}
+
+func (s *service) Patch(id int, columns map[string]interface{}, ifMatch string) (*Category, error) {
+	return s.repo.Patch(id, columns, ifMatch)
+}
+
+func (s *service) Move(id int, parentID *int, ifMatch string) (*Category, error) {
+	return s.repo.Move(id, parentID, ifMatch)
+}
+
+func (s *service) Archive(id int, ifMatch string, opts ArchiveOptions) error {
+	return s.repo.Archive(id, ifMatch, opts)
 }
 
-func (s *service) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *service) Restore(id int) (*Category, error) {
+	return s.repo.Restore(id)
 }
@@ -0,0 +1,76 @@
+package category
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// treeRepository returns fixed categories from GetTree. The other methods
+// are not used by the tests and panic.
+type treeRepository struct {
+	Repository
+	categories []Category
+}
+
+func (r treeRepository) GetTree(status string) ([]Category, error) {
+	return r.categories, nil
+}
+
+func TestServiceGetTree(t *testing.T) {
+	id := func(v int) *int { return &v }
+
+	// shape lists each node as "name[children...]"
+	var shape func(nodes []*CategoryNode) []string
+	shape = func(nodes []*CategoryNode) []string {
+		out := make([]string, 0, len(nodes))
+		for _, n := range nodes {
+			s := n.Name
+			if len(n.Children) > 0 {
+				s += "[" + strings.Join(shape(n.Children), " ") + "]"
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		categories []Category
+		want       []string
+	}{
+		{"empty", nil, []string{}},
+		{
+			"nested in repository order",
+			[]Category{
+				{ID: 1, Name: "Makanan"},
+				{ID: 2, Name: "Minuman"},
+				{ID: 3, Name: "Soda", ParentID: id(4)},
+				{ID: 4, Name: "Minuman Dingin", ParentID: id(2)},
+				{ID: 5, Name: "Kopi", ParentID: id(2)},
+			},
+			[]string{"Makanan", "Minuman[Minuman Dingin[Soda] Kopi]"},
+		},
+		{
+			"parent not listed goes to the top level",
+			[]Category{
+				{ID: 2, Name: "Minuman Dingin", ParentID: id(1)},
+				{ID: 3, Name: "Soda", ParentID: id(2)},
+			},
+			[]string{"Minuman Dingin[Soda]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(treeRepository{categories: tt.categories})
+			roots, err := s.GetTree(StatusActive)
+			if err != nil {
+				t.Fatalf("GetTree() error = %v", err)
+			}
+			if got := shape(roots); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTree() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
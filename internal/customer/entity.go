@@ -0,0 +1,97 @@
+package customer
+
+import (
+	"belajar-go/pkg/validation"
+	"time"
+)
+
+const (
+	EntryCharge  = "charge"
+	EntryPayment = "payment"
+)
+
+type Customer struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Phone         string    `json:"phone"`
+	CustomerGroup *string   `json:"customer_group"`
+	CreditLimit   int       `json:"credit_limit"`
+	Balance       int       `json:"balance"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CreateCustomerRequest struct {
+	Name          string  `json:"name"`
+	Phone         string  `json:"phone"`
+	CustomerGroup *string `json:"customer_group"`
+	CreditLimit   int     `json:"credit_limit"`
+}
+
+func (req CreateCustomerRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	v.Min("credit_limit", req.CreditLimit, 0)
+	return v.Errors()
+}
+
+type UpdateCustomerRequest struct {
+	Name          string  `json:"name"`
+	Phone         string  `json:"phone"`
+	CustomerGroup *string `json:"customer_group"`
+	CreditLimit   int     `json:"credit_limit"`
+}
+
+func (req UpdateCustomerRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	v.Min("credit_limit", req.CreditLimit, 0)
+	return v.Errors()
+}
+
+type LedgerEntry struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	EntryType     string    `json:"entry_type"`
+	Amount        int       `json:"amount"`
+	TransactionID *int      `json:"transaction_id"`
+	Note          string    `json:"note"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Ledger struct {
+	Customer Customer      `json:"customer"`
+	Entries  []LedgerEntry `json:"entries"`
+}
+
+type RepaymentRequest struct {
+	Amount int    `json:"amount"`
+	Note   string `json:"note"`
+}
+
+func (req RepaymentRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Min("amount", req.Amount, 1)
+	return v.Errors()
+}
+
+// AgingBucket groups outstanding receivables by the age of the charge
+// they belong to. Repayments settle the oldest charges first.
+type AgingBucket struct {
+	Days0To30  int `json:"0_30"`
+	Days31To60 int `json:"31_60"`
+	Days60Plus int `json:"60_plus"`
+	Total      int `json:"total"`
+}
+
+type CustomerAging struct {
+	CustomerID   int         `json:"customer_id"`
+	CustomerName string      `json:"customer_name"`
+	CreditLimit  int         `json:"credit_limit"`
+	Aging        AgingBucket `json:"aging"`
+}
+
+type AgingReport struct {
+	Customers []CustomerAging `json:"customers"`
+	Total     AgingBucket     `json:"total"`
+}
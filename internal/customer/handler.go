@@ -0,0 +1,147 @@
+package customer
+
+import (
+	"belajar-go/pkg/response"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.service.GetAll()
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, customers)
+}
+
+func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	customer, err := h.service.GetByID(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, customer)
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	customer, err := h.service.Create(req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, customer)
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req UpdateCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	customer, err := h.service.Update(id, req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, customer)
+}
+
+func (h *Handler) GetLedger(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	ledger, err := h.service.GetLedger(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, ledger)
+}
+
+func (h *Handler) Repay(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req RepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	entry, err := h.service.Repay(id, req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, entry)
+}
+
+func (h *Handler) GetAgingReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetAgingReport()
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, report)
+}
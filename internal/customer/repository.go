@@ -0,0 +1,184 @@
+package customer
+
+import (
+	"belajar-go/pkg/apperror"
+	"database/sql"
+)
+
+type Repository interface {
+	GetAll() ([]Customer, error)
+	GetByID(id int) (*Customer, error)
+	Create(req CreateCustomerRequest) (*Customer, error)
+	Update(id int, req UpdateCustomerRequest) (*Customer, error)
+	GetLedgerEntries(customerID int) ([]LedgerEntry, error)
+	CreateRepayment(customerID int, req RepaymentRequest) (*LedgerEntry, error)
+	GetAllLedgerEntries() ([]LedgerEntry, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+// balanceQuery derives the receivable balance of customer c from the ledger.
+const balanceQuery = `
+	COALESCE((
+		SELECT SUM(CASE WHEN l.entry_type = 'charge' THEN l.amount ELSE -l.amount END)
+		FROM customer_ledger l
+		WHERE l.customer_id = c.id
+	), 0)`
+
+func (r *repository) GetAll() ([]Customer, error) {
+	query := `SELECT c.id, c.name, c.phone, c.customer_group, c.credit_limit, ` + balanceQuery + `, c.created_at, c.updated_at
+		FROM customers c ORDER BY c.id ASC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var customers []Customer
+	for rows.Next() {
+		var cust Customer
+		if err := rows.Scan(&cust.ID, &cust.Name, &cust.Phone, &cust.CustomerGroup, &cust.CreditLimit, &cust.Balance, &cust.CreatedAt, &cust.UpdatedAt); err != nil {
+			return nil, err
+		}
+		customers = append(customers, cust)
+	}
+
+	return customers, nil
+}
+
+func (r *repository) GetByID(id int) (*Customer, error) {
+	query := `SELECT c.id, c.name, c.phone, c.customer_group, c.credit_limit, ` + balanceQuery + `, c.created_at, c.updated_at
+		FROM customers c WHERE c.id = $1`
+
+	var cust Customer
+	err := r.db.QueryRow(query, id).Scan(&cust.ID, &cust.Name, &cust.Phone, &cust.CustomerGroup, &cust.CreditLimit, &cust.Balance, &cust.CreatedAt, &cust.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &cust, nil
+}
+
+func (r *repository) Create(req CreateCustomerRequest) (*Customer, error) {
+	query := `INSERT INTO customers (name, phone, customer_group, credit_limit) VALUES ($1, $2, $3, $4)
+		RETURNING id, name, phone, customer_group, credit_limit, created_at, updated_at`
+
+	var cust Customer
+	err := r.db.QueryRow(query, req.Name, req.Phone, req.CustomerGroup, req.CreditLimit).Scan(
+		&cust.ID, &cust.Name, &cust.Phone, &cust.CustomerGroup, &cust.CreditLimit, &cust.CreatedAt, &cust.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cust, nil
+}
+
+func (r *repository) Update(id int, req UpdateCustomerRequest) (*Customer, error) {
+	query := `UPDATE customers SET name = $1, phone = $2, customer_group = $3, credit_limit = $4 WHERE id = $5 RETURNING id`
+
+	err := r.db.QueryRow(query, req.Name, req.Phone, req.CustomerGroup, req.CreditLimit, id).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(id)
+}
+
+func (r *repository) GetLedgerEntries(customerID int) ([]LedgerEntry, error) {
+	query := `SELECT id, customer_id, entry_type, amount, transaction_id, COALESCE(note, ''), created_at
+		FROM customer_ledger WHERE customer_id = $1 ORDER BY created_at ASC, id ASC`
+
+	return r.queryLedger(query, customerID)
+}
+
+func (r *repository) GetAllLedgerEntries() ([]LedgerEntry, error) {
+	query := `SELECT id, customer_id, entry_type, amount, transaction_id, COALESCE(note, ''), created_at
+		FROM customer_ledger ORDER BY customer_id ASC, created_at ASC, id ASC`
+
+	return r.queryLedger(query)
+}
+
+func (r *repository) queryLedger(query string, args ...interface{}) ([]LedgerEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]LedgerEntry, 0)
+	for rows.Next() {
+		var entry LedgerEntry
+		if err := rows.Scan(&entry.ID, &entry.CustomerID, &entry.EntryType, &entry.Amount,
+			&entry.TransactionID, &entry.Note, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (r *repository) CreateRepayment(customerID int, req RepaymentRequest) (*LedgerEntry, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the customer so concurrent charges and repayments see a consistent balance
+	err = tx.QueryRow("SELECT id FROM customers WHERE id = $1 FOR UPDATE", customerID).Scan(&customerID)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var balance int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN entry_type = 'charge' THEN amount ELSE -amount END), 0)
+		FROM customer_ledger WHERE customer_id = $1`, customerID).Scan(&balance)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount > balance {
+		return nil, apperror.Validation("repayment exceeds outstanding balance (balance: %d, requested: %d)", balance, req.Amount)
+	}
+
+	entry := LedgerEntry{
+		CustomerID: customerID,
+		EntryType:  EntryPayment,
+		Amount:     req.Amount,
+		Note:       req.Note,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO customer_ledger (customer_id, entry_type, amount, note)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		customerID, EntryPayment, req.Amount, req.Note,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
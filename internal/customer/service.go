@@ -0,0 +1,134 @@
+package customer
+
+import "time"
+
+type Service interface {
+	GetAll() ([]Customer, error)
+	GetByID(id int) (*Customer, error)
+	Create(req CreateCustomerRequest) (*Customer, error)
+	Update(id int, req UpdateCustomerRequest) (*Customer, error)
+	GetLedger(id int) (*Ledger, error)
+	Repay(id int, req RepaymentRequest) (*LedgerEntry, error)
+	GetAgingReport() (*AgingReport, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetAll() ([]Customer, error) {
+	return s.repo.GetAll()
+}
+
+func (s *service) GetByID(id int) (*Customer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) Create(req CreateCustomerRequest) (*Customer, error) {
+	return s.repo.Create(req)
+}
+
+func (s *service) Update(id int, req UpdateCustomerRequest) (*Customer, error) {
+	return s.repo.Update(id, req)
+}
+
+func (s *service) GetLedger(id int) (*Ledger, error) {
+	cust, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.GetLedgerEntries(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ledger{Customer: *cust, Entries: entries}, nil
+}
+
+func (s *service) Repay(id int, req RepaymentRequest) (*LedgerEntry, error) {
+	return s.repo.CreateRepayment(id, req)
+}
+
+func (s *service) GetAgingReport() (*AgingReport, error) {
+	customers, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.GetAllLedgerEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	byCustomer := make(map[int][]LedgerEntry)
+	for _, entry := range entries {
+		byCustomer[entry.CustomerID] = append(byCustomer[entry.CustomerID], entry)
+	}
+
+	now := time.Now()
+	report := &AgingReport{Customers: make([]CustomerAging, 0)}
+	for _, cust := range customers {
+		aging := ageEntries(byCustomer[cust.ID], now)
+		if aging.Total == 0 {
+			continue
+		}
+
+		report.Customers = append(report.Customers, CustomerAging{
+			CustomerID:   cust.ID,
+			CustomerName: cust.Name,
+			CreditLimit:  cust.CreditLimit,
+			Aging:        aging,
+		})
+
+		report.Total.Days0To30 += aging.Days0To30
+		report.Total.Days31To60 += aging.Days31To60
+		report.Total.Days60Plus += aging.Days60Plus
+		report.Total.Total += aging.Total
+	}
+
+	return report, nil
+}
+
+// ageEntries applies all payments to the oldest charges first and buckets
+// whatever remains unpaid by the age of its charge.
+func ageEntries(entries []LedgerEntry, now time.Time) AgingBucket {
+	paid := 0
+	for _, entry := range entries {
+		if entry.EntryType == EntryPayment {
+			paid += entry.Amount
+		}
+	}
+
+	var bucket AgingBucket
+	for _, entry := range entries {
+		if entry.EntryType != EntryCharge {
+			continue
+		}
+
+		outstanding := entry.Amount
+		if paid >= outstanding {
+			paid -= outstanding
+			continue
+		}
+		outstanding -= paid
+		paid = 0
+
+		days := int(now.Sub(entry.CreatedAt).Hours() / 24)
+		switch {
+		case days <= 30:
+			bucket.Days0To30 += outstanding
+		case days <= 60:
+			bucket.Days31To60 += outstanding
+		default:
+			bucket.Days60Plus += outstanding
+		}
+		bucket.Total += outstanding
+	}
+
+	return bucket
+}
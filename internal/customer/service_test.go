@@ -0,0 +1,83 @@
+package customer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeEntries(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+	charge := func(days, amount int) LedgerEntry {
+		return LedgerEntry{EntryType: EntryCharge, Amount: amount, CreatedAt: daysAgo(days)}
+	}
+	payment := func(days, amount int) LedgerEntry {
+		return LedgerEntry{EntryType: EntryPayment, Amount: amount, CreatedAt: daysAgo(days)}
+	}
+
+	tests := []struct {
+		name    string
+		entries []LedgerEntry
+		want    AgingBucket
+	}{
+		{
+			name: "no entries",
+			want: AgingBucket{},
+		},
+		{
+			name:    "charge today",
+			entries: []LedgerEntry{charge(0, 1000)},
+			want:    AgingBucket{Days0To30: 1000, Total: 1000},
+		},
+		{
+			name:    "30 days old is still current",
+			entries: []LedgerEntry{charge(30, 1000)},
+			want:    AgingBucket{Days0To30: 1000, Total: 1000},
+		},
+		{
+			name:    "31 days old",
+			entries: []LedgerEntry{charge(31, 1000)},
+			want:    AgingBucket{Days31To60: 1000, Total: 1000},
+		},
+		{
+			name:    "60 days old",
+			entries: []LedgerEntry{charge(60, 1000)},
+			want:    AgingBucket{Days31To60: 1000, Total: 1000},
+		},
+		{
+			name:    "61 days old",
+			entries: []LedgerEntry{charge(61, 1000)},
+			want:    AgingBucket{Days60Plus: 1000, Total: 1000},
+		},
+		{
+			name:    "payment settles the oldest charge first",
+			entries: []LedgerEntry{charge(90, 1000), charge(45, 2000), charge(5, 3000), payment(1, 1000)},
+			want:    AgingBucket{Days31To60: 2000, Days0To30: 3000, Total: 5000},
+		},
+		{
+			name:    "payment partly settles the oldest charge",
+			entries: []LedgerEntry{charge(90, 1000), charge(5, 3000), payment(1, 400)},
+			want:    AgingBucket{Days60Plus: 600, Days0To30: 3000, Total: 3600},
+		},
+		{
+			name:    "payment spans several charges",
+			entries: []LedgerEntry{charge(90, 1000), charge(45, 2000), charge(5, 3000), payment(50, 500), payment(1, 2000)},
+			want:    AgingBucket{Days31To60: 500, Days0To30: 3000, Total: 3500},
+		},
+		{
+			name:    "fully paid",
+			entries: []LedgerEntry{charge(90, 1000), charge(5, 3000), payment(1, 4000)},
+			want:    AgingBucket{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ageEntries(tt.entries, now); got != tt.want {
+				t.Errorf("ageEntries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
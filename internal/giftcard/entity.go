@@ -0,0 +1,33 @@
+package giftcard
+
+import "time"
+
+const (
+	MovementActivation = "activation"
+	MovementRedemption = "redemption"
+)
+
+type GiftCard struct {
+	ID             int       `json:"id"`
+	Code           string    `json:"code"`
+	ProductID      *int      `json:"product_id"`
+	InitialBalance int       `json:"initial_balance"`
+	Balance        int       `json:"balance"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Movement is a signed change to a card's balance: activations are
+// positive, redemptions negative. The balance is the sum of all movements.
+type Movement struct {
+	ID            int       `json:"id"`
+	GiftCardID    int       `json:"gift_card_id"`
+	MovementType  string    `json:"movement_type"`
+	Amount        int       `json:"amount"`
+	TransactionID *int      `json:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type History struct {
+	GiftCard  GiftCard   `json:"gift_card"`
+	Movements []Movement `json:"movements"`
+}
@@ -0,0 +1,34 @@
+package giftcard
+
+import (
+	"belajar-go/pkg/response"
+	"net/http"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetByCode(w http.ResponseWriter, r *http.Request) {
+	card, err := h.service.GetByCode(r.PathValue("code"))
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, card)
+}
+
+func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.service.GetHistory(r.PathValue("code"))
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, history)
+}
@@ -0,0 +1,72 @@
+package giftcard
+
+import (
+	"belajar-go/pkg/apperror"
+	"database/sql"
+)
+
+type Repository interface {
+	GetByCode(code string) (*GiftCard, error)
+	GetMovements(giftCardID int) ([]Movement, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetByCode(code string) (*GiftCard, error) {
+	query := `
+		SELECT
+			g.id,
+			g.code,
+			g.product_id,
+			g.initial_balance,
+			COALESCE((SELECT SUM(m.amount) FROM gift_card_movements m WHERE m.gift_card_id = g.id), 0),
+			g.created_at
+		FROM gift_cards g
+		WHERE g.code = $1
+	`
+
+	var card GiftCard
+	err := r.db.QueryRow(query, code).Scan(
+		&card.ID, &card.Code, &card.ProductID, &card.InitialBalance, &card.Balance, &card.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("gift card not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+func (r *repository) GetMovements(giftCardID int) ([]Movement, error) {
+	query := `
+		SELECT id, gift_card_id, movement_type, amount, transaction_id, created_at
+		FROM gift_card_movements
+		WHERE gift_card_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.Query(query, giftCardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]Movement, 0)
+	for rows.Next() {
+		var m Movement
+		if err := rows.Scan(&m.ID, &m.GiftCardID, &m.MovementType, &m.Amount, &m.TransactionID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, nil
+}
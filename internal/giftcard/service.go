@@ -0,0 +1,32 @@
+package giftcard
+
+type Service interface {
+	GetByCode(code string) (*GiftCard, error)
+	GetHistory(code string) (*History, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetByCode(code string) (*GiftCard, error) {
+	return s.repo.GetByCode(code)
+}
+
+func (s *service) GetHistory(code string) (*History, error) {
+	card, err := s.repo.GetByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := s.repo.GetMovements(card.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &History{GiftCard: *card, Movements: movements}, nil
+}
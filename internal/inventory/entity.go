@@ -0,0 +1,59 @@
+package inventory
+
+import (
+	"belajar-go/pkg/validation"
+	"time"
+)
+
+// StockCount is a physical count of a product. SystemQty is the stock the
+// system expected at the time of the count; recording the count sets the
+// product stock to CountedQty.
+type StockCount struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	Nama       string    `json:"nama"`
+	SystemQty  int       `json:"system_qty"`
+	CountedQty int       `json:"counted_qty"`
+	Selisih    int       `json:"selisih"`
+	Note       *string   `json:"note"`
+	CountedAt  time.Time `json:"counted_at"`
+}
+
+type CreateStockCountRequest struct {
+	ProductID  int     `json:"product_id"`
+	CountedQty int     `json:"counted_qty"`
+	Note       *string `json:"note"`
+}
+
+func (req CreateStockCountRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Min("product_id", req.ProductID, 1)
+	v.Min("counted_qty", req.CountedQty, 0)
+	return v.Errors()
+}
+
+// IngredientUsageReport compares the ingredient usage implied by recipe
+// sales against stock counts over a date range.
+type IngredientUsageReport struct {
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Ingredients []IngredientUsage `json:"ingredients"`
+}
+
+// IngredientUsage covers the period between the last count before From
+// (StokAwal) and the last count up to To (StokAkhir). PemakaianTeoritis is
+// what recipe sales consumed in that period, Selisih the closing count minus
+// the stock the system expected, and PemakaianAktual the theoretical usage
+// adjusted by that difference. Count fields are null when no count exists.
+type IngredientUsage struct {
+	ProductID         int        `json:"product_id"`
+	Nama              string     `json:"nama"`
+	BaseUnit          string     `json:"base_unit"`
+	StokAwal          *int       `json:"stok_awal"`
+	DihitungAwal      *time.Time `json:"dihitung_awal"`
+	StokAkhir         *int       `json:"stok_akhir"`
+	DihitungAkhir     *time.Time `json:"dihitung_akhir"`
+	PemakaianTeoritis int        `json:"pemakaian_teoritis"`
+	Selisih           *int       `json:"selisih"`
+	PemakaianAktual   *int       `json:"pemakaian_aktual"`
+}
@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"belajar-go/pkg/response"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetStockCounts(w http.ResponseWriter, r *http.Request) {
+	productID := 0
+	if idStr := r.URL.Query().Get("product_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid product_id")
+			return
+		}
+		productID = id
+	}
+
+	counts, err := h.service.GetStockCounts(productID)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, counts)
+}
+
+func (h *Handler) CreateStockCount(w http.ResponseWriter, r *http.Request) {
+	var req CreateStockCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	count, err := h.service.CreateStockCount(req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, count)
+}
+
+func (h *Handler) GetIngredientUsageReport(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+			return
+		}
+	}
+
+	report, err := h.service.GetIngredientUsageReport(from, to)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, report)
+}
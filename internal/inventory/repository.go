@@ -0,0 +1,192 @@
+package inventory
+
+import (
+	"belajar-go/internal/product"
+	"belajar-go/pkg/apperror"
+	"database/sql"
+)
+
+type Repository interface {
+	GetStockCounts(productID int) ([]StockCount, error)
+	CreateStockCount(req CreateStockCountRequest) (*StockCount, error)
+	GetIngredientUsageReport(from, to string) (*IngredientUsageReport, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetStockCounts(productID int) ([]StockCount, error) {
+	query := `
+		SELECT sc.id, sc.product_id, p.nama, sc.system_qty, sc.counted_qty, sc.note, sc.counted_at
+		FROM stock_counts sc
+		JOIN products p ON sc.product_id = p.id
+	`
+
+	args := []interface{}{}
+	if productID > 0 {
+		query += " WHERE sc.product_id = $1"
+		args = append(args, productID)
+	}
+	query += " ORDER BY sc.counted_at DESC, sc.id DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]StockCount, 0)
+	for rows.Next() {
+		var sc StockCount
+		if err := rows.Scan(&sc.ID, &sc.ProductID, &sc.Nama, &sc.SystemQty, &sc.CountedQty, &sc.Note, &sc.CountedAt); err != nil {
+			return nil, err
+		}
+		sc.Selisih = sc.CountedQty - sc.SystemQty
+		counts = append(counts, sc)
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *repository) CreateStockCount(req CreateStockCountRequest) (*StockCount, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sc := StockCount{ProductID: req.ProductID, CountedQty: req.CountedQty, Note: req.Note}
+
+	// Bundle and recipe stock is derived from their parts, so only products
+	// holding their own stock can be counted
+	var tipe string
+	var hasRecipe bool
+	err = tx.QueryRow(`
+		SELECT nama, stok, tipe, EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id)
+		FROM products p WHERE p.id = $1 FOR UPDATE`, req.ProductID).
+		Scan(&sc.Nama, &sc.SystemQty, &tipe, &hasRecipe)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product id %d not found", req.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tipe == product.TypeBundle || hasRecipe {
+		return nil, apperror.Validation("stock of %s is derived from its components and cannot be counted", sc.Nama)
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO stock_counts (product_id, system_qty, counted_qty, note)
+		VALUES ($1, $2, $3, $4) RETURNING id, counted_at`,
+		sc.ProductID, sc.SystemQty, sc.CountedQty, sc.Note,
+	).Scan(&sc.ID, &sc.CountedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE products SET stok = $1 WHERE id = $2", sc.CountedQty, sc.ProductID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	sc.Selisih = sc.CountedQty - sc.SystemQty
+	return &sc, nil
+}
+
+func (r *repository) GetIngredientUsageReport(from, to string) (*IngredientUsageReport, error) {
+	var fromArg, toArg interface{}
+	if from != "" {
+		fromArg = from
+	}
+	if to != "" {
+		toArg = to
+	}
+
+	report := &IngredientUsageReport{Ingredients: make([]IngredientUsage, 0)}
+	err := r.db.QueryRow("SELECT COALESCE($1::date, CURRENT_DATE)::text, COALESCE($2::date, CURRENT_DATE)::text", fromArg, toArg).
+		Scan(&report.From, &report.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// Usage is measured between the opening and closing counts, falling back
+	// to the report boundaries when an ingredient has not been counted
+	rows, err := r.db.Query(`
+		SELECT
+			p.id,
+			p.nama,
+			p.base_unit,
+			o.counted_qty,
+			o.counted_at,
+			c.counted_qty,
+			c.counted_at,
+			c.system_qty,
+			COALESCE((
+				SELECT SUM(tdi.quantity)
+				FROM transaction_detail_ingredients tdi
+				JOIN transaction_details td ON tdi.transaction_detail_id = td.id
+				JOIN transactions t ON td.transaction_id = t.id
+				WHERE tdi.product_id = p.id
+					AND t.created_at >= COALESCE(o.counted_at, $1::date)
+					AND t.created_at < COALESCE(c.counted_at, $2::date + 1)
+			), 0)
+		FROM products p
+		LEFT JOIN LATERAL (
+			SELECT counted_qty, counted_at FROM stock_counts
+			WHERE product_id = p.id AND counted_at < $1::date
+			ORDER BY counted_at DESC, id DESC LIMIT 1
+		) o ON true
+		LEFT JOIN LATERAL (
+			SELECT counted_qty, counted_at, system_qty FROM stock_counts
+			WHERE product_id = p.id AND counted_at >= $1::date AND counted_at < $2::date + 1
+			ORDER BY counted_at DESC, id DESC LIMIT 1
+		) c ON true
+		WHERE EXISTS (SELECT 1 FROM recipe_items ri WHERE ri.ingredient_id = p.id)
+			OR EXISTS (SELECT 1 FROM transaction_detail_ingredients tdi WHERE tdi.product_id = p.id)
+		ORDER BY p.nama ASC, p.id ASC
+	`, report.From, report.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u IngredientUsage
+		var openingQty, closingQty, closingSystemQty sql.NullInt64
+		var openingAt, closingAt sql.NullTime
+		if err := rows.Scan(&u.ProductID, &u.Nama, &u.BaseUnit, &openingQty, &openingAt,
+			&closingQty, &closingAt, &closingSystemQty, &u.PemakaianTeoritis); err != nil {
+			return nil, err
+		}
+
+		if openingQty.Valid {
+			qty := int(openingQty.Int64)
+			u.StokAwal = &qty
+			u.DihitungAwal = &openingAt.Time
+		}
+		if closingQty.Valid {
+			qty := int(closingQty.Int64)
+			selisih := qty - int(closingSystemQty.Int64)
+			aktual := u.PemakaianTeoritis - selisih
+			u.StokAkhir = &qty
+			u.DihitungAkhir = &closingAt.Time
+			u.Selisih = &selisih
+			u.PemakaianAktual = &aktual
+		}
+
+		report.Ingredients = append(report.Ingredients, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
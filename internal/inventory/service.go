@@ -0,0 +1,27 @@
+package inventory
+
+type Service interface {
+	GetStockCounts(productID int) ([]StockCount, error)
+	CreateStockCount(req CreateStockCountRequest) (*StockCount, error)
+	GetIngredientUsageReport(from, to string) (*IngredientUsageReport, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetStockCounts(productID int) ([]StockCount, error) {
+	return s.repo.GetStockCounts(productID)
+}
+
+func (s *service) CreateStockCount(req CreateStockCountRequest) (*StockCount, error) {
+	return s.repo.CreateStockCount(req)
+}
+
+func (s *service) GetIngredientUsageReport(from, to string) (*IngredientUsageReport, error) {
+	return s.repo.GetIngredientUsageReport(from, to)
+}
@@ -0,0 +1,83 @@
+package modifier
+
+import (
+	"belajar-go/pkg/validation"
+	"time"
+)
+
+// ModifierGroup is a set of choices attached to products, such as "Level
+// Gula" or "Topping". A group with MinSelect > 0 is required; MaxSelect of
+// 0 means there is no upper limit.
+type ModifierGroup struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Required  bool       `json:"required"`
+	MinSelect int        `json:"min_select"`
+	MaxSelect int        `json:"max_select"`
+	Modifiers []Modifier `json:"modifiers"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Modifier is a single choice in a group. PriceDelta is added to the unit
+// price of the line; when ProductID is set, choosing the modifier also
+// deducts Quantity (in that product's base unit) per unit sold.
+type Modifier struct {
+	ID          int     `json:"id"`
+	GroupID     int     `json:"group_id"`
+	Name        string  `json:"name"`
+	PriceDelta  int     `json:"price_delta"`
+	ProductID   *int    `json:"product_id"`
+	ProductName *string `json:"product_name,omitempty"`
+	Quantity    int     `json:"quantity"`
+}
+
+type CreateModifierGroupRequest struct {
+	Name      string `json:"name"`
+	Required  bool   `json:"required"`
+	MinSelect int    `json:"min_select"`
+	MaxSelect int    `json:"max_select"`
+}
+
+type UpdateModifierGroupRequest struct {
+	Name      string `json:"name"`
+	Required  bool   `json:"required"`
+	MinSelect int    `json:"min_select"`
+	MaxSelect int    `json:"max_select"`
+}
+
+func (req CreateModifierGroupRequest) Validate() validation.Errors {
+	return validateGroup(req.Name, req.MinSelect, req.MaxSelect)
+}
+
+func (req UpdateModifierGroupRequest) Validate() validation.Errors {
+	return validateGroup(req.Name, req.MinSelect, req.MaxSelect)
+}
+
+func validateGroup(name string, minSelect, maxSelect int) validation.Errors {
+	var v validation.Validator
+	v.Required("name", name)
+	v.Min("min_select", minSelect, 0)
+	v.Min("max_select", maxSelect, 0)
+	if maxSelect > 0 {
+		v.Check(maxSelect >= minSelect, "max_select", validation.CodeInvalid, "max_select cannot be less than min_select")
+	}
+	return v.Errors()
+}
+
+type CreateModifierRequest struct {
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+	ProductID  *int   `json:"product_id"`
+	Quantity   int    `json:"quantity"`
+}
+
+func (req CreateModifierRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	if req.ProductID != nil {
+		v.Min("product_id", *req.ProductID, 1)
+	}
+	v.Min("quantity", req.Quantity, 0)
+	return v.Errors()
+}
@@ -0,0 +1,233 @@
+package modifier
+
+import (
+	"belajar-go/pkg/apperror"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	GetAll() ([]ModifierGroup, error)
+	GetByID(id int) (*ModifierGroup, error)
+	Create(req CreateModifierGroupRequest) (*ModifierGroup, error)
+	Update(id int, req UpdateModifierGroupRequest) (*ModifierGroup, error)
+	Delete(id int) error
+	GetModifiers(groupIDs []int) ([]Modifier, error)
+	CreateModifier(groupID int, req CreateModifierRequest) (*Modifier, error)
+	DeleteModifier(groupID, modifierID int) error
+	GetProductGroups(productID int) ([]ModifierGroup, error)
+	SetProductGroups(productID int, groupIDs []int) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]ModifierGroup, error) {
+	query := `SELECT id, name, required, min_select, max_select, created_at, updated_at
+		FROM modifier_groups ORDER BY id ASC`
+
+	return r.queryGroups(query)
+}
+
+func (r *repository) GetByID(id int) (*ModifierGroup, error) {
+	query := `SELECT id, name, required, min_select, max_select, created_at, updated_at
+		FROM modifier_groups WHERE id = $1`
+
+	var g ModifierGroup
+	err := r.db.QueryRow(query, id).Scan(&g.ID, &g.Name, &g.Required, &g.MinSelect, &g.MaxSelect, &g.CreatedAt, &g.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("modifier group not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+func (r *repository) Create(req CreateModifierGroupRequest) (*ModifierGroup, error) {
+	query := `INSERT INTO modifier_groups (name, required, min_select, max_select) VALUES ($1, $2, $3, $4)
+		RETURNING id, name, required, min_select, max_select, created_at, updated_at`
+
+	var g ModifierGroup
+	err := r.db.QueryRow(query, req.Name, req.Required, req.MinSelect, req.MaxSelect).Scan(
+		&g.ID, &g.Name, &g.Required, &g.MinSelect, &g.MaxSelect, &g.CreatedAt, &g.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+func (r *repository) Update(id int, req UpdateModifierGroupRequest) (*ModifierGroup, error) {
+	query := `UPDATE modifier_groups SET name = $1, required = $2, min_select = $3, max_select = $4 WHERE id = $5
+		RETURNING id, name, required, min_select, max_select, created_at, updated_at`
+
+	var g ModifierGroup
+	err := r.db.QueryRow(query, req.Name, req.Required, req.MinSelect, req.MaxSelect, id).Scan(
+		&g.ID, &g.Name, &g.Required, &g.MinSelect, &g.MaxSelect, &g.CreatedAt, &g.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("modifier group not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+func (r *repository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM modifier_groups WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("modifier group not found")
+	}
+
+	return nil
+}
+
+func (r *repository) GetModifiers(groupIDs []int) ([]Modifier, error) {
+	query := `
+		SELECT m.id, m.group_id, m.name, m.price_delta, m.product_id, p.nama, m.quantity
+		FROM modifiers m
+		LEFT JOIN products p ON m.product_id = p.id
+		WHERE m.group_id = ANY($1)
+		ORDER BY m.group_id ASC, m.id ASC
+	`
+
+	rows, err := r.db.Query(query, pq.Array(groupIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	modifiers := make([]Modifier, 0)
+	for rows.Next() {
+		var m Modifier
+		if err := rows.Scan(&m.ID, &m.GroupID, &m.Name, &m.PriceDelta, &m.ProductID, &m.ProductName, &m.Quantity); err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, m)
+	}
+
+	return modifiers, rows.Err()
+}
+
+func (r *repository) CreateModifier(groupID int, req CreateModifierRequest) (*Modifier, error) {
+	query := `
+		INSERT INTO modifiers (group_id, name, price_delta, product_id, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, group_id, name, price_delta, product_id, quantity
+	`
+
+	var m Modifier
+	err := r.db.QueryRow(query, groupID, req.Name, req.PriceDelta, req.ProductID, req.Quantity).Scan(
+		&m.ID, &m.GroupID, &m.Name, &m.PriceDelta, &m.ProductID, &m.Quantity,
+	)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return nil, apperror.NotFound("product id %d not found", *req.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (r *repository) DeleteModifier(groupID, modifierID int) error {
+	result, err := r.db.Exec(`DELETE FROM modifiers WHERE id = $1 AND group_id = $2`, modifierID, groupID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("modifier not found")
+	}
+
+	return nil
+}
+
+func (r *repository) GetProductGroups(productID int) ([]ModifierGroup, error) {
+	query := `
+		SELECT g.id, g.name, g.required, g.min_select, g.max_select, g.created_at, g.updated_at
+		FROM product_modifier_groups pmg
+		JOIN modifier_groups g ON pmg.group_id = g.id
+		WHERE pmg.product_id = $1
+		ORDER BY pmg.position ASC, g.id ASC
+	`
+
+	return r.queryGroups(query, productID)
+}
+
+func (r *repository) SetProductGroups(productID int, groupIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return apperror.NotFound("product not found")
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_modifier_groups WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+
+	for i, groupID := range groupIDs {
+		_, err := tx.Exec("INSERT INTO product_modifier_groups (product_id, group_id, position) VALUES ($1, $2, $3)",
+			productID, groupID, i)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return apperror.NotFound("modifier group id %d not found", groupID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) queryGroups(query string, args ...interface{}) ([]ModifierGroup, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]ModifierGroup, 0)
+	for rows.Next() {
+		var g ModifierGroup
+		if err := rows.Scan(&g.ID, &g.Name, &g.Required, &g.MinSelect, &g.MaxSelect, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
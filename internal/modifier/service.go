@@ -0,0 +1,140 @@
+package modifier
+
+type Service interface {
+	GetAll() ([]ModifierGroup, error)
+	GetByID(id int) (*ModifierGroup, error)
+	Create(req CreateModifierGroupRequest) (*ModifierGroup, error)
+	Update(id int, req UpdateModifierGroupRequest) (*ModifierGroup, error)
+	Delete(id int) error
+	AddModifier(groupID int, req CreateModifierRequest) (*Modifier, error)
+	DeleteModifier(groupID, modifierID int) error
+	GetProductGroups(productID int) ([]ModifierGroup, error)
+	SetProductGroups(productID int, groupIDs []int) ([]ModifierGroup, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetAll() ([]ModifierGroup, error) {
+	groups, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.attachModifiers(groups)
+}
+
+func (s *service) GetByID(id int) (*ModifierGroup, error) {
+	g, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.attachModifiers([]ModifierGroup{*g})
+	if err != nil {
+		return nil, err
+	}
+
+	return &groups[0], nil
+}
+
+func (s *service) Create(req CreateModifierGroupRequest) (*ModifierGroup, error) {
+	req.Required, req.MinSelect = normalizeRequired(req.Required, req.MinSelect)
+
+	g, err := s.repo.Create(req)
+	if err != nil {
+		return nil, err
+	}
+	g.Modifiers = make([]Modifier, 0)
+
+	return g, nil
+}
+
+func (s *service) Update(id int, req UpdateModifierGroupRequest) (*ModifierGroup, error) {
+	req.Required, req.MinSelect = normalizeRequired(req.Required, req.MinSelect)
+
+	if _, err := s.repo.Update(id, req); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+func (s *service) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *service) AddModifier(groupID int, req CreateModifierRequest) (*Modifier, error) {
+	if _, err := s.repo.GetByID(groupID); err != nil {
+		return nil, err
+	}
+
+	if req.ProductID == nil {
+		req.Quantity = 0
+	} else if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	return s.repo.CreateModifier(groupID, req)
+}
+
+func (s *service) DeleteModifier(groupID, modifierID int) error {
+	return s.repo.DeleteModifier(groupID, modifierID)
+}
+
+func (s *service) GetProductGroups(productID int) ([]ModifierGroup, error) {
+	groups, err := s.repo.GetProductGroups(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.attachModifiers(groups)
+}
+
+func (s *service) SetProductGroups(productID int, groupIDs []int) ([]ModifierGroup, error) {
+	if err := s.repo.SetProductGroups(productID, groupIDs); err != nil {
+		return nil, err
+	}
+
+	return s.GetProductGroups(productID)
+}
+
+func (s *service) attachModifiers(groups []ModifierGroup) ([]ModifierGroup, error) {
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	ids := make([]int, len(groups))
+	index := make(map[int]int, len(groups))
+	for i := range groups {
+		ids[i] = groups[i].ID
+		index[groups[i].ID] = i
+		groups[i].Modifiers = make([]Modifier, 0)
+	}
+
+	modifiers, err := s.repo.GetModifiers(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modifiers {
+		i := index[m.GroupID]
+		groups[i].Modifiers = append(groups[i].Modifiers, m)
+	}
+
+	return groups, nil
+}
+
+// normalizeRequired keeps required and min_select consistent: a required
+// group needs at least one selection and a group with a minimum is required.
+func normalizeRequired(required bool, minSelect int) (bool, int) {
+	if required && minSelect == 0 {
+		minSelect = 1
+	}
+
+	return minSelect > 0, minSelect
+}
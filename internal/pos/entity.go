@@ -0,0 +1,73 @@
+package pos
+
+import (
+	"belajar-go/pkg/validation"
+	"fmt"
+)
+
+// Layout is what the touchscreen POS needs to draw its grid: the category
+// tabs, the product buttons and the pinned favorites, each in display order.
+// Items without a position come after the positioned ones, by name.
+type Layout struct {
+	Categories []LayoutCategory `json:"categories"`
+	Products   []LayoutProduct  `json:"products"`
+	Favorites  []int            `json:"favorites"`
+}
+
+type LayoutCategory struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	ParentID *int    `json:"parent_id"`
+	Color    *string `json:"color"`
+	Icon     *string `json:"icon"`
+}
+
+// LayoutProduct is a product button. Variants are not listed; HasVariants
+// tells the POS to open the variant picker of the product instead.
+type LayoutProduct struct {
+	ID           int     `json:"id"`
+	Nama         string  `json:"nama"`
+	Harga        int     `json:"harga"`
+	Tipe         string  `json:"tipe"`
+	CategoryID   *int    `json:"category_id"`
+	Color        *string `json:"color"`
+	Icon         *string `json:"icon"`
+	ThumbnailURL *string `json:"thumbnail_url"`
+	HasVariants  bool    `json:"has_variants"`
+}
+
+// ReorderRequest lists categories or products in their new display order,
+// as left by a drag-and-drop. Items that are not listed keep their
+// position.
+type ReorderRequest struct {
+	IDs []int `json:"ids"`
+}
+
+func (req ReorderRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Check(len(req.IDs) > 0, "ids", validation.CodeRequired, "ids is required")
+	validateIDs(&v, "ids", req.IDs)
+	return v.Errors()
+}
+
+// FavoritesRequest replaces the pinned products with ProductIDs, in order.
+// An empty list unpins every product.
+type FavoritesRequest struct {
+	ProductIDs []int `json:"product_ids"`
+}
+
+func (req FavoritesRequest) Validate() validation.Errors {
+	var v validation.Validator
+	validateIDs(&v, "product_ids", req.ProductIDs)
+	return v.Errors()
+}
+
+func validateIDs(v *validation.Validator, field string, ids []int) {
+	seen := make(map[int]bool)
+	for i, id := range ids {
+		idField := fmt.Sprintf("%s[%d]", field, i)
+		v.Min(idField, id, 1)
+		v.Check(!seen[id], idField, validation.CodeDuplicate, fmt.Sprintf("duplicate id %d", id))
+		seen[id] = true
+	}
+}
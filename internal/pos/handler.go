@@ -0,0 +1,100 @@
+package pos
+
+import (
+	"belajar-go/pkg/etag"
+	"belajar-go/pkg/response"
+	"encoding/json"
+	"net/http"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetLayout returns the POS layout with an ETag, so a terminal polling for
+// changes gets a 304 while nothing moved.
+func (h *Handler) GetLayout(w http.ResponseWriter, r *http.Request) {
+	layout, err := h.service.GetLayout()
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	tag, err := etag.Hash(layout)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+	if etag.NotModified(w, r, tag) {
+		return
+	}
+
+	response.Success(w, http.StatusOK, layout)
+}
+
+func (h *Handler) ReorderCategories(w http.ResponseWriter, r *http.Request) {
+	var req ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	layout, err := h.service.ReorderCategories(req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, layout)
+}
+
+func (h *Handler) ReorderProducts(w http.ResponseWriter, r *http.Request) {
+	var req ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	layout, err := h.service.ReorderProducts(req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, layout)
+}
+
+func (h *Handler) SetFavorites(w http.ResponseWriter, r *http.Request) {
+	var req FavoritesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	layout, err := h.service.SetFavorites(req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, layout)
+}
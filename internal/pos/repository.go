@@ -0,0 +1,201 @@
+package pos
+
+import (
+	"belajar-go/internal/product"
+	"belajar-go/pkg/validation"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	GetLayout() (*Layout, error)
+	ReorderCategories(ids []int) error
+	ReorderProducts(ids []int) error
+	SetFavorites(productIDs []int) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+// GetLayout reads the active categories and products in display order.
+func (r *repository) GetLayout() (*Layout, error) {
+	layout := &Layout{
+		Categories: make([]LayoutCategory, 0),
+		Products:   make([]LayoutProduct, 0),
+		Favorites:  make([]int, 0),
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, name, parent_id, color, icon
+		FROM categories
+		WHERE archived_at IS NULL
+		ORDER BY position, name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c LayoutCategory
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &c.Color, &c.Icon); err != nil {
+			return nil, err
+		}
+		layout.Categories = append(layout.Categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`
+		SELECT
+			p.id,
+			p.nama,
+			p.harga,
+			p.tipe,
+			p.category_id,
+			p.color,
+			p.icon,
+			p.thumbnail_key IS NOT NULL,
+			EXISTS(SELECT 1 FROM products v WHERE v.parent_id = p.id AND v.archived_at IS NULL)
+		FROM products p
+		WHERE p.parent_id IS NULL AND p.archived_at IS NULL
+		ORDER BY p.position, p.nama, p.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p LayoutProduct
+		var hasThumbnail bool
+		if err := rows.Scan(&p.ID, &p.Nama, &p.Harga, &p.Tipe, &p.CategoryID, &p.Color, &p.Icon,
+			&hasThumbnail, &p.HasVariants); err != nil {
+			return nil, err
+		}
+		if hasThumbnail {
+			url := product.ThumbnailURL(p.ID)
+			p.ThumbnailURL = &url
+		}
+		layout.Products = append(layout.Products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`
+		SELECT id
+		FROM products
+		WHERE favorite_order IS NOT NULL AND parent_id IS NULL AND archived_at IS NULL
+		ORDER BY favorite_order, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		layout.Favorites = append(layout.Favorites, id)
+	}
+
+	return layout, rows.Err()
+}
+
+// ReorderCategories numbers the given active categories 1, 2, 3, ... in
+// order.
+func (r *repository) ReorderCategories(ids []int) error {
+	return r.reorder("categories", "position", "category",
+		"SELECT id FROM categories WHERE id = ANY($1) AND archived_at IS NULL FOR UPDATE", "ids", ids)
+}
+
+// ReorderProducts numbers the given active products 1, 2, 3, ... in order.
+// Variants are not on the grid and cannot be reordered.
+func (r *repository) ReorderProducts(ids []int) error {
+	return r.reorder("products", "position", "product",
+		"SELECT id FROM products WHERE id = ANY($1) AND parent_id IS NULL AND archived_at IS NULL FOR UPDATE", "ids", ids)
+}
+
+// SetFavorites pins the given active products in order and unpins the
+// rest.
+func (r *repository) SetFavorites(productIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE products SET favorite_order = NULL WHERE favorite_order IS NOT NULL AND NOT (id = ANY($1))",
+		pq.Array(productIDs))
+	if err != nil {
+		return err
+	}
+
+	err = setOrder(tx, "products", "favorite_order", "product",
+		"SELECT id FROM products WHERE id = ANY($1) AND parent_id IS NULL AND archived_at IS NULL FOR UPDATE", "product_ids", productIDs)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) reorder(table, column, noun, lockQuery, field string, ids []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setOrder(tx, table, column, noun, lockQuery, field, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setOrder locks the rows selected by lockQuery, reports the ids it does
+// not return as not found, and sets column to the 1-based index of each id.
+// Rows already in place are not updated, so their version does not move.
+func setOrder(tx *sql.Tx, table, column, noun, lockQuery, field string, ids []int) error {
+	rows, err := tx.Query(lockQuery, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	found := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		found[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var v validation.Validator
+	for i, id := range ids {
+		v.Check(found[id], fmt.Sprintf("%s[%d]", field, i), validation.CodeNotFound, fmt.Sprintf("%s id %d not found", noun, id))
+	}
+	if errs := v.Errors(); errs != nil {
+		return errs
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		UPDATE %[1]s t
+		SET %[2]s = x.ord
+		FROM unnest($1::int[]) WITH ORDINALITY AS x(id, ord)
+		WHERE t.id = x.id AND t.%[2]s IS DISTINCT FROM x.ord`, table, column), pq.Array(ids))
+	return err
+}
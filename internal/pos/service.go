@@ -0,0 +1,43 @@
+package pos
+
+type Service interface {
+	GetLayout() (*Layout, error)
+	ReorderCategories(req ReorderRequest) (*Layout, error)
+	ReorderProducts(req ReorderRequest) (*Layout, error)
+	SetFavorites(req FavoritesRequest) (*Layout, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetLayout() (*Layout, error) {
+	return s.repo.GetLayout()
+}
+
+// ReorderCategories, ReorderProducts and SetFavorites return the updated
+// layout, so the POS can redraw without fetching it again.
+func (s *service) ReorderCategories(req ReorderRequest) (*Layout, error) {
+	if err := s.repo.ReorderCategories(req.IDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetLayout()
+}
+
+func (s *service) ReorderProducts(req ReorderRequest) (*Layout, error) {
+	if err := s.repo.ReorderProducts(req.IDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetLayout()
+}
+
+func (s *service) SetFavorites(req FavoritesRequest) (*Layout, error) {
+	if err := s.repo.SetFavorites(req.ProductIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetLayout()
+}
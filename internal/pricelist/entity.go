@@ -0,0 +1,65 @@
+package pricelist
+
+import (
+	"belajar-go/pkg/validation"
+	"time"
+)
+
+// PriceList overrides products.harga for customers of a group. A list
+// without a customer group applies to every customer, which is how
+// quantity-break prices for walk-in customers are configured.
+type PriceList struct {
+	ID            int             `json:"id"`
+	Name          string          `json:"name"`
+	CustomerGroup *string         `json:"customer_group"`
+	Items         []PriceListItem `json:"items"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// PriceListItem is a unit price for a product that applies once the line
+// quantity reaches MinQty.
+type PriceListItem struct {
+	ID          int    `json:"id"`
+	PriceListID int    `json:"price_list_id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	MinQty      int    `json:"min_qty"`
+	Harga       int    `json:"harga"`
+}
+
+type CreatePriceListRequest struct {
+	Name          string  `json:"name"`
+	CustomerGroup *string `json:"customer_group"`
+}
+
+func (req CreatePriceListRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	return v.Errors()
+}
+
+type UpdatePriceListRequest struct {
+	Name          string  `json:"name"`
+	CustomerGroup *string `json:"customer_group"`
+}
+
+func (req UpdatePriceListRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	return v.Errors()
+}
+
+type CreatePriceListItemRequest struct {
+	ProductID int `json:"product_id"`
+	MinQty    int `json:"min_qty"`
+	Harga     int `json:"harga"`
+}
+
+func (req CreatePriceListItemRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Min("product_id", req.ProductID, 1)
+	v.Min("min_qty", req.MinQty, 0)
+	v.Min("harga", req.Harga, 0)
+	return v.Errors()
+}
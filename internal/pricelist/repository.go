@@ -0,0 +1,182 @@
+package pricelist
+
+import (
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/validation"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	GetAll() ([]PriceList, error)
+	GetByID(id int) (*PriceList, error)
+	Create(req CreatePriceListRequest) (*PriceList, error)
+	Update(id int, req UpdatePriceListRequest) (*PriceList, error)
+	Delete(id int) error
+	GetItems(priceListID int) ([]PriceListItem, error)
+	CreateItem(priceListID int, req CreatePriceListItemRequest) (*PriceListItem, error)
+	DeleteItem(priceListID, itemID int) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]PriceList, error) {
+	query := `SELECT id, name, customer_group, created_at, updated_at FROM price_lists ORDER BY id ASC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]PriceList, 0)
+	for rows.Next() {
+		var pl PriceList
+		if err := rows.Scan(&pl.ID, &pl.Name, &pl.CustomerGroup, &pl.CreatedAt, &pl.UpdatedAt); err != nil {
+			return nil, err
+		}
+		lists = append(lists, pl)
+	}
+
+	return lists, rows.Err()
+}
+
+func (r *repository) GetByID(id int) (*PriceList, error) {
+	query := `SELECT id, name, customer_group, created_at, updated_at FROM price_lists WHERE id = $1`
+
+	var pl PriceList
+	err := r.db.QueryRow(query, id).Scan(&pl.ID, &pl.Name, &pl.CustomerGroup, &pl.CreatedAt, &pl.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("price list not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pl, nil
+}
+
+func (r *repository) Create(req CreatePriceListRequest) (*PriceList, error) {
+	query := `INSERT INTO price_lists (name, customer_group) VALUES ($1, $2)
+		RETURNING id, name, customer_group, created_at, updated_at`
+
+	var pl PriceList
+	err := r.db.QueryRow(query, req.Name, req.CustomerGroup).Scan(
+		&pl.ID, &pl.Name, &pl.CustomerGroup, &pl.CreatedAt, &pl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pl, nil
+}
+
+func (r *repository) Update(id int, req UpdatePriceListRequest) (*PriceList, error) {
+	query := `UPDATE price_lists SET name = $1, customer_group = $2 WHERE id = $3
+		RETURNING id, name, customer_group, created_at, updated_at`
+
+	var pl PriceList
+	err := r.db.QueryRow(query, req.Name, req.CustomerGroup, id).Scan(
+		&pl.ID, &pl.Name, &pl.CustomerGroup, &pl.CreatedAt, &pl.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("price list not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pl, nil
+}
+
+func (r *repository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM price_lists WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("price list not found")
+	}
+
+	return nil
+}
+
+func (r *repository) GetItems(priceListID int) ([]PriceListItem, error) {
+	query := `
+		SELECT i.id, i.price_list_id, i.product_id, p.nama, i.min_qty, i.harga
+		FROM price_list_items i
+		JOIN products p ON i.product_id = p.id
+		WHERE i.price_list_id = $1
+		ORDER BY i.product_id ASC, i.min_qty ASC
+	`
+
+	rows, err := r.db.Query(query, priceListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]PriceListItem, 0)
+	for rows.Next() {
+		var item PriceListItem
+		if err := rows.Scan(&item.ID, &item.PriceListID, &item.ProductID, &item.ProductName, &item.MinQty, &item.Harga); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *repository) CreateItem(priceListID int, req CreatePriceListItemRequest) (*PriceListItem, error) {
+	query := `
+		INSERT INTO price_list_items (price_list_id, product_id, min_qty, harga)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (price_list_id, product_id, min_qty) DO UPDATE SET harga = EXCLUDED.harga
+		RETURNING id, price_list_id, product_id, min_qty, harga
+	`
+
+	var item PriceListItem
+	err := r.db.QueryRow(query, priceListID, req.ProductID, req.MinQty, req.Harga).Scan(
+		&item.ID, &item.PriceListID, &item.ProductID, &item.MinQty, &item.Harga,
+	)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" && pqErr.Constraint == "price_list_items_product_id_fkey" {
+		return nil, validation.Field("product_id", validation.CodeNotFound, "product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (r *repository) DeleteItem(priceListID, itemID int) error {
+	result, err := r.db.Exec(`DELETE FROM price_list_items WHERE id = $1 AND price_list_id = $2`, itemID, priceListID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("price list item not found")
+	}
+
+	return nil
+}
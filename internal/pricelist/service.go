@@ -0,0 +1,65 @@
+package pricelist
+
+type Service interface {
+	GetAll() ([]PriceList, error)
+	GetByID(id int) (*PriceList, error)
+	Create(req CreatePriceListRequest) (*PriceList, error)
+	Update(id int, req UpdatePriceListRequest) (*PriceList, error)
+	Delete(id int) error
+	AddItem(priceListID int, req CreatePriceListItemRequest) (*PriceListItem, error)
+	DeleteItem(priceListID, itemID int) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetAll() ([]PriceList, error) {
+	return s.repo.GetAll()
+}
+
+func (s *service) GetByID(id int) (*PriceList, error) {
+	pl, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	pl.Items, err = s.repo.GetItems(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return pl, nil
+}
+
+func (s *service) Create(req CreatePriceListRequest) (*PriceList, error) {
+	return s.repo.Create(req)
+}
+
+func (s *service) Update(id int, req UpdatePriceListRequest) (*PriceList, error) {
+	return s.repo.Update(id, req)
+}
+
+func (s *service) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *service) AddItem(priceListID int, req CreatePriceListItemRequest) (*PriceListItem, error) {
+	if _, err := s.repo.GetByID(priceListID); err != nil {
+		return nil, err
+	}
+
+	if req.MinQty == 0 {
+		req.MinQty = 1
+	}
+
+	return s.repo.CreateItem(priceListID, req)
+}
+
+func (s *service) DeleteItem(priceListID, itemID int) error {
+	return s.repo.DeleteItem(priceListID, itemID)
+}
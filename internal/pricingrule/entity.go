@@ -0,0 +1,97 @@
+package pricingrule
+
+import (
+	"belajar-go/pkg/storetime"
+	"belajar-go/pkg/validation"
+	"fmt"
+	"time"
+)
+
+// PricingRule discounts a product, or every product in a category, during a
+// recurring time window. Days are ISO weekdays (1 = Monday, 7 = Sunday) and
+// times are evaluated in the store timezone. A window whose end is before
+// its start runs past midnight.
+type PricingRule struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	ProductID       *int      `json:"product_id"`
+	CategoryID      *int      `json:"category_id"`
+	Days            []int64   `json:"days"`
+	StartTime       string    `json:"start_time"`
+	EndTime         string    `json:"end_time"`
+	DiscountPercent int       `json:"discount_percent"`
+	Active          bool      `json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// InWindow reports whether t, in the store timezone, falls on one of the
+// rule's days from StartTime up to, not including, EndTime. A window that
+// runs past midnight matches both its late and its early hours on each of
+// its days.
+func (r PricingRule) InWindow(t time.Time) bool {
+	weekday := int64(storetime.ISOWeekday(t))
+	onDay := false
+	for _, d := range r.Days {
+		if d == weekday {
+			onDay = true
+			break
+		}
+	}
+	if !onDay {
+		return false
+	}
+
+	now := t.Format("15:04")
+	if r.StartTime <= r.EndTime {
+		return now >= r.StartTime && now < r.EndTime
+	}
+	return now >= r.StartTime || now < r.EndTime
+}
+
+type CreatePricingRuleRequest struct {
+	Name            string  `json:"name"`
+	ProductID       *int    `json:"product_id"`
+	CategoryID      *int    `json:"category_id"`
+	Days            []int64 `json:"days"`
+	StartTime       string  `json:"start_time"`
+	EndTime         string  `json:"end_time"`
+	DiscountPercent int     `json:"discount_percent"`
+	Active          *bool   `json:"active"`
+}
+
+type UpdatePricingRuleRequest struct {
+	Name            string  `json:"name"`
+	ProductID       *int    `json:"product_id"`
+	CategoryID      *int    `json:"category_id"`
+	Days            []int64 `json:"days"`
+	StartTime       string  `json:"start_time"`
+	EndTime         string  `json:"end_time"`
+	DiscountPercent int     `json:"discount_percent"`
+	Active          *bool   `json:"active"`
+}
+
+func (req CreatePricingRuleRequest) Validate() validation.Errors {
+	return validateRule(req.Name, req.ProductID, req.CategoryID, req.Days, req.StartTime, req.EndTime, req.DiscountPercent)
+}
+
+func (req UpdatePricingRuleRequest) Validate() validation.Errors {
+	return validateRule(req.Name, req.ProductID, req.CategoryID, req.Days, req.StartTime, req.EndTime, req.DiscountPercent)
+}
+
+func validateRule(name string, productID, categoryID *int, days []int64, startTime, endTime string, discountPercent int) validation.Errors {
+	var v validation.Validator
+	v.Required("name", name)
+	v.Check((productID == nil) != (categoryID == nil), "product_id", validation.CodeRequired, "exactly one of product_id or category_id is required")
+	v.Check(len(days) > 0, "days", validation.CodeRequired, "days cannot be empty")
+	for i, d := range days {
+		v.Range(fmt.Sprintf("days[%d]", i), int(d), 1, 7)
+	}
+	_, err := time.Parse("15:04", startTime)
+	v.Check(err == nil, "start_time", validation.CodeInvalid, "start_time must use HH:MM format")
+	_, err = time.Parse("15:04", endTime)
+	v.Check(err == nil, "end_time", validation.CodeInvalid, "end_time must use HH:MM format")
+	v.Check(startTime != endTime, "end_time", validation.CodeInvalid, "start_time and end_time cannot be equal")
+	v.Range("discount_percent", discountPercent, 1, 100)
+	return v.Errors()
+}
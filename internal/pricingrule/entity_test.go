@@ -0,0 +1,48 @@
+package pricingrule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPricingRuleInWindow(t *testing.T) {
+	// 2026-03-02 is a Monday
+	at := func(day int, clock string) time.Time {
+		c, err := time.Parse("15:04", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2026, 3, 1+day, c.Hour(), c.Minute(), 30, 0, time.UTC)
+	}
+	happyHour := PricingRule{Days: []int64{1, 2, 3, 4, 5}, StartTime: "15:00", EndTime: "17:00"}
+	lateNight := PricingRule{Days: []int64{5, 6}, StartTime: "22:00", EndTime: "02:00"}
+
+	tests := []struct {
+		name string
+		rule PricingRule
+		at   time.Time
+		want bool
+	}{
+		{"inside window", happyHour, at(1, "16:00"), true},
+		{"at start", happyHour, at(1, "15:00"), true},
+		{"just before end", happyHour, at(1, "16:59"), true},
+		{"at end", happyHour, at(1, "17:00"), false},
+		{"before start", happyHour, at(1, "14:59"), false},
+		{"other day", happyHour, at(6, "16:00"), false},
+		{"sunday is day 7", PricingRule{Days: []int64{7}, StartTime: "08:00", EndTime: "12:00"}, at(7, "09:00"), true},
+		{"wrapping window before midnight", lateNight, at(5, "23:30"), true},
+		{"wrapping window at start", lateNight, at(5, "22:00"), true},
+		{"wrapping window after midnight", lateNight, at(6, "01:00"), true},
+		{"wrapping window at end", lateNight, at(6, "02:00"), false},
+		{"wrapping window in the gap", lateNight, at(5, "12:00"), false},
+		{"wrapping window early hours of another day", lateNight, at(7, "01:00"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.InWindow(tt.at); got != tt.want {
+				t.Errorf("InWindow(%s) = %v, want %v", tt.at.Format("Mon 15:04:05"), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package pricingrule
+
+import (
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/validation"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	GetAll() ([]PricingRule, error)
+	GetActive() ([]PricingRule, error)
+	GetByID(id int) (*PricingRule, error)
+	Create(req CreatePricingRuleRequest) (*PricingRule, error)
+	Update(id int, req UpdatePricingRuleRequest) (*PricingRule, error)
+	Delete(id int) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+const selectColumns = `
+	id, name, product_id, category_id, days,
+	to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'),
+	discount_percent, active, created_at, updated_at`
+
+func scanRule(scanner interface{ Scan(...interface{}) error }) (*PricingRule, error) {
+	var rule PricingRule
+	err := scanner.Scan(
+		&rule.ID, &rule.Name, &rule.ProductID, &rule.CategoryID, pq.Array(&rule.Days),
+		&rule.StartTime, &rule.EndTime, &rule.DiscountPercent, &rule.Active, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *repository) query(query string, args ...interface{}) ([]PricingRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]PricingRule, 0)
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, nil
+}
+
+func (r *repository) GetAll() ([]PricingRule, error) {
+	return r.query(`SELECT ` + selectColumns + ` FROM pricing_rules ORDER BY id ASC`)
+}
+
+func (r *repository) GetActive() ([]PricingRule, error) {
+	return r.query(`SELECT ` + selectColumns + ` FROM pricing_rules WHERE active ORDER BY id ASC`)
+}
+
+func (r *repository) GetByID(id int) (*PricingRule, error) {
+	rule, err := scanRule(r.db.QueryRow(`SELECT `+selectColumns+` FROM pricing_rules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("pricing rule not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *repository) Create(req CreatePricingRuleRequest) (*PricingRule, error) {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	query := `
+		INSERT INTO pricing_rules (name, product_id, category_id, days, start_time, end_time, discount_percent, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + selectColumns
+
+	rule, err := scanRule(r.db.QueryRow(query, req.Name, req.ProductID, req.CategoryID, pq.Array(req.Days),
+		req.StartTime, req.EndTime, req.DiscountPercent, active))
+	if err != nil {
+		return nil, referenceError(err)
+	}
+
+	return rule, nil
+}
+
+func (r *repository) Update(id int, req UpdatePricingRuleRequest) (*PricingRule, error) {
+	query := `
+		UPDATE pricing_rules
+		SET name = $1, product_id = $2, category_id = $3, days = $4, start_time = $5, end_time = $6,
+			discount_percent = $7, active = COALESCE($8, active)
+		WHERE id = $9
+		RETURNING ` + selectColumns
+
+	rule, err := scanRule(r.db.QueryRow(query, req.Name, req.ProductID, req.CategoryID, pq.Array(req.Days),
+		req.StartTime, req.EndTime, req.DiscountPercent, req.Active, id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("pricing rule not found")
+	}
+	if err != nil {
+		return nil, referenceError(err)
+	}
+
+	return rule, nil
+}
+
+func (r *repository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM pricing_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("pricing rule not found")
+	}
+
+	return nil
+}
+
+// referenceError reports a product_id or category_id that does not exist
+// as a field error instead of a raw foreign key violation.
+func referenceError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		switch pqErr.Constraint {
+		case "pricing_rules_product_id_fkey":
+			return validation.Field("product_id", validation.CodeNotFound, "product not found")
+		case "pricing_rules_category_id_fkey":
+			return validation.Field("category_id", validation.CodeNotFound, "category not found")
+		}
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package pricingrule
+
+import "belajar-go/pkg/storetime"
+
+type Service interface {
+	GetAll() ([]PricingRule, error)
+	GetActiveNow() ([]PricingRule, error)
+	GetByID(id int) (*PricingRule, error)
+	Create(req CreatePricingRuleRequest) (*PricingRule, error)
+	Update(id int, req UpdatePricingRuleRequest) (*PricingRule, error)
+	Delete(id int) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetAll() ([]PricingRule, error) {
+	return s.repo.GetAll()
+}
+
+func (s *service) GetActiveNow() ([]PricingRule, error) {
+	rules, err := s.repo.GetActive()
+	if err != nil {
+		return nil, err
+	}
+
+	now := storetime.Now()
+	active := make([]PricingRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.InWindow(now) {
+			active = append(active, rule)
+		}
+	}
+	return active, nil
+}
+
+func (s *service) GetByID(id int) (*PricingRule, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) Create(req CreatePricingRuleRequest) (*PricingRule, error) {
+	return s.repo.Create(req)
+}
+
+func (s *service) Update(id int, req UpdatePricingRuleRequest) (*PricingRule, error) {
+	return s.repo.Update(id, req)
+}
+
+func (s *service) Delete(id int) error {
+	return s.repo.Delete(id)
+}
@@ -1,8 +1,40 @@
 package product
 
 import (
+	"belajar-go/pkg/barcode"
+	"belajar-go/pkg/pagination"
+	"belajar-go/pkg/validation"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	TypeStandard = "standard"
+	TypeGiftCard = "gift_card"
+	TypeBundle   = "bundle"
+)
+
+const DefaultBaseUnit = "pcs"
+
+// MaxIconLength matches the icon column. Icons are names from the icon set
+// of the POS, such as "coffee".
+const MaxIconLength = 50
+
+// Listing filters on the archive state of products.
+const (
+	StatusActive   = "active"
+	StatusArchived = "archived"
+	StatusAll      = "all"
+)
+
+const (
+	PriceSourceManual    = "manual"
+	PriceSourceScheduled = "scheduled"
+	PriceSourceBulk      = "bulk"
 )
 
 type Product struct {
@@ -10,45 +42,328 @@ type Product struct {
 	Nama       string    `json:"nama"`
 	Harga      int       `json:"harga"`
 	Stok       int       `json:"stok"`
+	Tipe       string    `json:"tipe"`
+	SKU        *string   `json:"sku"`
+	Barcodes   []string  `json:"barcodes"`
+	BaseUnit   string    `json:"base_unit"`
 	CategoryID *int      `json:"category_id"`
+	Color      *string   `json:"color"`
+	Icon       *string   `json:"icon"`
+	Version    int       `json:"version"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
+// scan reads the columns listed in productColumns. Barcodes are stored in
+// their own table and read separately.
+func (p *Product) scan(row interface{ Scan(...interface{}) error }) error {
+	return row.Scan(&p.ID, &p.Nama, &p.Harga, &p.Stok, &p.Tipe, &p.SKU, &p.BaseUnit, &p.CategoryID,
+		&p.Color, &p.Icon, &p.Version, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// ProductDetail is a product with its category. A parent product lists
+// its variants and the option matrix they span; a variant carries its
+// ParentID and the option values that identify it (e.g. Ukuran: L).
 type ProductDetail struct {
-	ID           int       `json:"id"`
-	Nama         string    `json:"nama"`
-	Harga        int       `json:"harga"`
-	Stok         int       `json:"stok"`
-	CategoryID   *int      `json:"category_id"`
-	CategoryName *string   `json:"category_name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             int               `json:"id"`
+	Nama           string            `json:"nama"`
+	Harga          int               `json:"harga"`
+	Stok           int               `json:"stok"`
+	Tipe           string            `json:"tipe"`
+	SKU            *string           `json:"sku"`
+	Barcodes       []string          `json:"barcodes"`
+	BaseUnit       string            `json:"base_unit"`
+	Units          []ProductUnit     `json:"units,omitempty"`
+	Components     []BundleComponent `json:"components,omitempty"`
+	Recipe         []RecipeItem      `json:"recipe,omitempty"`
+	CategoryID     *int              `json:"category_id"`
+	CategoryName   *string           `json:"category_name"`
+	ParentID       *int              `json:"parent_id,omitempty"`
+	Options        map[string]string `json:"options,omitempty"`
+	VariantOptions []VariantOption   `json:"variant_options,omitempty"`
+	Variants       []ProductDetail   `json:"variants,omitempty"`
+	ImageURL       *string           `json:"image_url"`
+	ThumbnailURL   *string           `json:"thumbnail_url"`
+	Color          *string           `json:"color"`
+	Icon           *string           `json:"icon"`
+	Position       *int              `json:"position"`
+	FavoriteOrder  *int              `json:"favorite_order"`
+	Archived       bool              `json:"archived"`
+	ArchivedAt     *time.Time        `json:"archived_at"`
+	Version        int               `json:"version"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+
+	// Storage keys of the image, served through ImageURL and ThumbnailURL
+	imageKey     *string
+	thumbnailKey *string
+}
+
+type VariantOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ProductUnit is an alternative unit a product is sold in. Conversion is
+// the number of base units in one of this unit (a carton of 12 bottles has
+// conversion 12). Harga, when set, is the price of one unit; otherwise the
+// base price times Conversion is charged. Stock is always held in base units.
+type ProductUnit struct {
+	ID         int    `json:"id"`
+	ProductID  int    `json:"product_id"`
+	Name       string `json:"name"`
+	Conversion int    `json:"conversion"`
+	Harga      *int   `json:"harga"`
+}
+
+type CreateUnitRequest struct {
+	Name       string `json:"name"`
+	Conversion int    `json:"conversion"`
+	Harga      *int   `json:"harga"`
+}
+
+func (req CreateUnitRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("name", req.Name)
+	v.Min("conversion", req.Conversion, 1)
+	if req.Harga != nil {
+		v.Min("harga", *req.Harga, 0)
+	}
+	return v.Errors()
+}
+
+// BundleComponent is a product contained in a bundle. Quantity is in the
+// component's base unit per one bundle sold.
+type BundleComponent struct {
+	ComponentID int    `json:"component_id"`
+	Nama        string `json:"nama,omitempty"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ProductPatch is a validated JSON Merge Patch for a product. Columns maps
+// each provided column to its new value, with nil clearing the column.
+// Barcodes is nil when the patch leaves them untouched.
+type ProductPatch struct {
+	Columns  map[string]interface{}
+	Barcodes *[]string
+}
+
+// ProductFilter narrows GET /products. Nil fields are not filtered on; the
+// stock range applies to the available stock, so bundles and recipe
+// products are filtered on what their parts can make. Status defaults to
+// active, hiding archived products.
+type ProductFilter struct {
+	Status       string
+	Name         string
+	CategoryID   *int
+	MinHarga     *int
+	MaxHarga     *int
+	MinStok      *int
+	MaxStok      *int
+	UpdatedSince *time.Time
+	pagination.Params
+}
+
+// RecipeItem is an ingredient consumed when one unit of the product is
+// sold. Quantity is in the ingredient's base unit (e.g. 50 gram).
+type RecipeItem struct {
+	IngredientID int    `json:"ingredient_id"`
+	Nama         string `json:"nama,omitempty"`
+	BaseUnit     string `json:"base_unit,omitempty"`
+	Quantity     int    `json:"quantity"`
+}
+
+type CreateVariantRequest struct {
+	Harga    int               `json:"harga"`
+	Stok     int               `json:"stok"`
+	SKU      *string           `json:"sku"`
+	Barcodes []string          `json:"barcodes"`
+	Options  map[string]string `json:"options"`
+}
+
+func (req CreateVariantRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Min("harga", req.Harga, 0)
+	v.Min("stok", req.Stok, 0)
+	v.Check(len(req.Options) > 0, "options", validation.CodeRequired, "options is required")
+	for name, value := range req.Options {
+		if name == "" || value == "" {
+			v.Add("options", validation.CodeInvalid, "option names and values cannot be empty")
+			break
+		}
+	}
+	validateBarcodes(&v, req.Barcodes)
+	return v.Errors()
 }
 
 type CreateProductRequest struct {
-	Nama       string `json:"nama"`
-	Harga      int    `json:"harga"`
-	Stok       int    `json:"stok"`
-	CategoryID *int   `json:"category_id"`
+	Nama       string   `json:"nama"`
+	Harga      int      `json:"harga"`
+	Stok       int      `json:"stok"`
+	Tipe       string   `json:"tipe"`
+	SKU        *string  `json:"sku"`
+	Barcodes   []string `json:"barcodes"`
+	BaseUnit   string   `json:"base_unit"`
+	CategoryID *int     `json:"category_id"`
+	Color      *string  `json:"color"`
+	Icon       *string  `json:"icon"`
+}
+
+// Validate checks the fields that do not need the database. Whether the
+// category exists is checked by the repository.
+func (req CreateProductRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("nama", req.Nama)
+	v.Min("harga", req.Harga, 0)
+	v.Min("stok", req.Stok, 0)
+	v.OneOf("tipe", req.Tipe, TypeStandard, TypeGiftCard, TypeBundle)
+	v.Required("base_unit", req.BaseUnit)
+	validateBarcodes(&v, req.Barcodes)
+	if req.CategoryID != nil {
+		v.Min("category_id", *req.CategoryID, 1)
+	}
+	validateDisplay(&v, req.Color, req.Icon)
+	return v.Errors()
 }
 
 type UpdateProductRequest struct {
-	Nama       string `json:"nama"`
-	Harga      int    `json:"harga"`
-	Stok       int    `json:"stok"`
-	CategoryID *int   `json:"category_id"`
+	Nama       string   `json:"nama"`
+	Harga      int      `json:"harga"`
+	Stok       int      `json:"stok"`
+	SKU        *string  `json:"sku"`
+	Barcodes   []string `json:"barcodes"`
+	BaseUnit   string   `json:"base_unit"`
+	CategoryID *int     `json:"category_id"`
+	Color      *string  `json:"color"`
+	Icon       *string  `json:"icon"`
+}
+
+func (req UpdateProductRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Required("nama", req.Nama)
+	v.Min("harga", req.Harga, 0)
+	v.Min("stok", req.Stok, 0)
+	v.Required("base_unit", req.BaseUnit)
+	validateBarcodes(&v, req.Barcodes)
+	if req.CategoryID != nil {
+		v.Min("category_id", *req.CategoryID, 1)
+	}
+	validateDisplay(&v, req.Color, req.Icon)
+	return v.Errors()
+}
+
+// validateDisplay checks the optional color and icon shown on the POS.
+func validateDisplay(v *validation.Validator, color, icon *string) {
+	if color != nil {
+		v.Color("color", *color)
+	}
+	if icon != nil {
+		v.Required("icon", *icon)
+		v.MaxLength("icon", *icon, MaxIconLength)
+	}
+}
+
+// validateBarcodes checks each barcode and rejects duplicates in the list.
+func validateBarcodes(v *validation.Validator, codes []string) {
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		field := fmt.Sprintf("barcodes[%d]", i)
+		if err := barcode.Validate(code); err != nil {
+			v.Add(field, validation.CodeInvalid, err.Error())
+			continue
+		}
+		v.Check(!seen[code], field, validation.CodeDuplicate, "duplicate barcode "+code)
+		seen[code] = true
+	}
+}
+
+// PriceHistory is a price that applies to a product from EffectiveFrom
+// until EffectiveTo (open-ended when nil). When several rows overlap the
+// one with the latest EffectiveFrom wins.
+type PriceHistory struct {
+	ID            int        `json:"id"`
+	ProductID     int        `json:"product_id"`
+	Harga         int        `json:"harga"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	Source        string     `json:"source"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type SchedulePriceRequest struct {
+	Harga         int        `json:"harga"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+}
+
+func (req SchedulePriceRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Min("harga", req.Harga, 0)
+	v.Check(!req.EffectiveFrom.IsZero(), "effective_from", validation.CodeRequired, "effective_from is required")
+	if req.EffectiveTo != nil {
+		v.Check(req.EffectiveTo.After(req.EffectiveFrom), "effective_to", validation.CodeInvalid, "effective_to must be after effective_from")
+	}
+	return v.Errors()
+}
+
+// ImportRow is one line of a bulk import file. Line is the line number in
+// the file so errors can be traced back to the spreadsheet. Action and
+// ProductID are filled in once the row has been applied.
+type ImportRow struct {
+	Line      int               `json:"line"`
+	Nama      string            `json:"nama"`
+	Harga     int               `json:"harga"`
+	Stok      int               `json:"stok"`
+	Category  string            `json:"category,omitempty"`
+	SKU       string            `json:"sku,omitempty"`
+	Barcodes  []string          `json:"barcodes,omitempty"`
+	Action    string            `json:"action,omitempty"`
+	ProductID *int              `json:"product_id,omitempty"`
+	Errors    validation.Errors `json:"errors,omitempty"`
+}
+
+// ImportResult reports what a bulk import did, or would do in a dry run.
+// Nothing is saved unless Valid is true.
+type ImportResult struct {
+	DryRun            bool        `json:"dry_run"`
+	Valid             bool        `json:"valid"`
+	Created           int         `json:"created"`
+	Updated           int         `json:"updated"`
+	CategoriesCreated []string    `json:"categories_created"`
+	Rows              []ImportRow `json:"rows"`
+}
+
+type PriceAt struct {
+	ProductID int           `json:"product_id"`
+	At        time.Time     `json:"at"`
+	Harga     int           `json:"harga"`
+	Source    *PriceHistory `json:"source"`
 }
 
 func (p *ProductDetail) ScanRow(rows *sql.Rows) error {
 	var categoryName sql.NullString
+	var options []byte
 	err := rows.Scan(
 		&p.ID,
 		&p.Nama,
 		&p.Harga,
 		&p.Stok,
+		&p.Tipe,
+		&p.SKU,
+		pq.Array(&p.Barcodes),
+		&p.BaseUnit,
 		&p.CategoryID,
 		&categoryName,
+		&p.ParentID,
+		&options,
+		&p.imageKey,
+		&p.thumbnailKey,
+		&p.Color,
+		&p.Icon,
+		&p.Position,
+		&p.FavoriteOrder,
+		&p.ArchivedAt,
+		&p.Version,
 		&p.CreatedAt,
 		&p.UpdatedAt,
 	)
@@ -56,9 +371,23 @@ func (p *ProductDetail) ScanRow(rows *sql.Rows) error {
 		return err
 	}
 
+	if options != nil {
+		if err := json.Unmarshal(options, &p.Options); err != nil {
+			return err
+		}
+	}
+
 	if categoryName.Valid {
 		p.CategoryName = &categoryName.String
 	}
 
+	if p.imageKey != nil {
+		image := newProductImage(p.ID)
+		p.ImageURL = &image.ImageURL
+		p.ThumbnailURL = &image.ThumbnailURL
+	}
+
+	p.Archived = p.ArchivedAt != nil
+
 	return nil
 }
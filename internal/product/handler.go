@@ -1,10 +1,24 @@
 package product
 
 import (
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/barcode"
+	"belajar-go/pkg/etag"
+	"belajar-go/pkg/pagination"
+	"belajar-go/pkg/patch"
 	"belajar-go/pkg/response"
+	"belajar-go/pkg/spreadsheet"
+	"belajar-go/pkg/validation"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Handler struct {
@@ -16,15 +30,71 @@ func NewHandler(service Service) *Handler {
 }
 
 func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
-	nameFilter := r.URL.Query().Get("name")
+	q := r.URL.Query()
 
-	products, err := h.service.GetAll(nameFilter)
+	params, err := pagination.Parse(q, productSortColumns, "id")
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	response.Success(w, http.StatusOK, products)
+	filter := ProductFilter{Status: q.Get("status"), Name: q.Get("name"), Params: params}
+	switch filter.Status {
+	case "", StatusActive, StatusArchived, StatusAll:
+	default:
+		response.Error(w, http.StatusBadRequest, "Invalid status, use active, archived or all")
+		return
+	}
+
+	intFilters := []struct {
+		key    string
+		target **int
+	}{
+		{"category_id", &filter.CategoryID},
+		{"min_harga", &filter.MinHarga},
+		{"max_harga", &filter.MaxHarga},
+		{"min_stok", &filter.MinStok},
+		{"max_stok", &filter.MaxStok},
+	}
+	for _, f := range intFilters {
+		v := q.Get(f.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid "+f.key)
+			return
+		}
+		*f.target = &n
+	}
+
+	if v := q.Get("updated_since"); v != "" {
+		since, err := parseTime(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid updated_since, use RFC3339 or YYYY-MM-DD")
+			return
+		}
+		filter.UpdatedSince = &since
+	}
+
+	products, total, err := h.service.GetAll(filter)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	meta := params.Meta(total)
+	tag, err := etag.Hash([]interface{}{products, meta})
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+	if etag.NotModified(w, r, tag) {
+		return
+	}
+
+	response.Paginated(w, http.StatusOK, products, meta)
 }
 
 func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -37,13 +107,60 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	product, err := h.service.GetByID(id)
 	if err != nil {
-		response.Error(w, http.StatusNotFound, err.Error())
+		response.FromError(w, err)
+		return
+	}
+
+	if etag.NotModified(w, r, etag.Version(product.Version)) {
 		return
 	}
 
 	response.Success(w, http.StatusOK, product)
 }
 
+func (h *Handler) GetByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	if err := barcode.Validate(code); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product, err := h.service.GetByBarcode(code)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, product)
+}
+
+// Search serves the cashier search box: GET /products/search?q=indomi&limit=10.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		response.Error(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	limit := DefaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSearchLimit {
+			response.Error(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", MaxSearchLimit))
+			return
+		}
+		limit = n
+	}
+
+	products, err := h.service.Search(q, limit)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, products)
+}
+
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	var req CreateProductRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -51,15 +168,106 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Tipe == "" {
+		req.Tipe = TypeStandard
+	}
+	if req.BaseUnit == "" {
+		req.BaseUnit = DefaultBaseUnit
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
 	product, err := h.service.Create(req)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.FromError(w, err)
 		return
 	}
 
 	response.Success(w, http.StatusCreated, product)
 }
 
+// MaxImportSize is the largest file accepted by Import.
+const MaxImportSize = 10 << 20
+
+// Import creates or updates products from a CSV or XLSX file uploaded in the
+// "file" form field. With ?dry_run=true nothing is saved and the response
+// shows what would happen to each row.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Upload a CSV or XLSX file (max 10 MB) in the file field")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid file")
+		return
+	}
+
+	records, err := spreadsheet.Read(header.Filename, data)
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		response.FromError(w, err)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	result, err := h.service.Import(records, dryRun)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	if !result.Valid && !dryRun {
+		response.JSON(w, http.StatusUnprocessableEntity, response.Response{
+			Success: false,
+			Message: "Import failed, no rows were saved",
+			Data:    result,
+		})
+		return
+	}
+
+	response.Success(w, http.StatusOK, result)
+}
+
+func (h *Handler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req CreateVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	variant, err := h.service.CreateVariant(id, req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, variant)
+}
+
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.Atoi(idStr)
@@ -68,21 +276,158 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifMatch, ok := etag.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req UpdateProductRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	product, err := h.service.Update(id, req)
+	if req.BaseUnit == "" {
+		req.BaseUnit = DefaultBaseUnit
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	product, err := h.service.Update(id, req, ifMatch)
 	if err != nil {
-		response.Error(w, http.StatusNotFound, err.Error())
+		response.FromError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.Version(product.Version))
 	response.Success(w, http.StatusOK, product)
 }
 
+// Patch applies a JSON Merge Patch: omitted fields are left untouched and
+// null clears sku, category_id, barcodes, color or icon.
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	ifMatch, ok := etag.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var doc patch.Document
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	p, errs := decodeProductPatch(doc)
+	if errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	product, err := h.service.Patch(id, p, ifMatch)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.Version(product.Version))
+	response.Success(w, http.StatusOK, product)
+}
+
+func decodeProductPatch(doc patch.Document) (ProductPatch, validation.Errors) {
+	p := ProductPatch{Columns: make(map[string]interface{})}
+	var v validation.Validator
+
+	for key := range doc {
+		switch key {
+		case "nama", "base_unit":
+			var s string
+			if _, err := doc.Decode(key, &s, false); err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			v.Required(key, s)
+			p.Columns[key] = s
+		case "harga", "stok":
+			var n int
+			if _, err := doc.Decode(key, &n, false); err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			v.Min(key, n, 0)
+			p.Columns[key] = n
+		case "sku":
+			var s string
+			isNull, err := doc.Decode(key, &s, true)
+			if err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			if isNull || s == "" {
+				p.Columns[key] = nil
+			} else {
+				p.Columns[key] = s
+			}
+		case "category_id":
+			var n int
+			isNull, err := doc.Decode(key, &n, true)
+			if err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			if isNull {
+				p.Columns[key] = nil
+			} else {
+				v.Min(key, n, 1)
+				p.Columns[key] = n
+			}
+		case "barcodes":
+			var codes []string
+			if _, err := doc.Decode(key, &codes, true); err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			validateBarcodes(&v, codes)
+			if codes == nil {
+				codes = []string{}
+			}
+			p.Barcodes = &codes
+		case "color", "icon":
+			var s string
+			isNull, err := doc.Decode(key, &s, true)
+			if err != nil {
+				v.Add(key, validation.CodeInvalid, err.Error())
+				continue
+			}
+			if isNull {
+				p.Columns[key] = nil
+				continue
+			}
+			if key == "color" {
+				validateDisplay(&v, &s, nil)
+			} else {
+				validateDisplay(&v, nil, &s)
+			}
+			p.Columns[key] = s
+		case "tipe":
+			v.Add(key, validation.CodeInvalid, "tipe cannot be changed")
+		default:
+			v.Add(key, validation.CodeInvalid, "unknown field "+key)
+		}
+	}
+
+	return p, v.Errors()
+}
+
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.Atoi(idStr)
@@ -91,8 +436,376 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(id); err != nil {
-		response.Error(w, http.StatusNotFound, err.Error())
+	ifMatch, ok := etag.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err = h.service.Archive(id, ifMatch)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	product, err := h.service.Restore(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, product)
+}
+
+func (h *Handler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req SchedulePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	schedule, err := h.service.SchedulePrice(id, req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, schedule)
+}
+
+func (h *Handler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	history, err := h.service.GetPriceHistory(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, history)
+}
+
+func (h *Handler) GetPriceAt(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	at := time.Now()
+	if atStr := r.URL.Query().Get("at"); atStr != "" {
+		at, err = parseTime(atStr)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid at, use RFC3339 or YYYY-MM-DD")
+			return
+		}
+	}
+
+	price, err := h.service.GetPriceAt(id, at)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, price)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+func (h *Handler) GetUnits(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	units, err := h.service.GetUnits(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, units)
+}
+
+func (h *Handler) CreateUnit(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req CreateUnitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	unit, err := h.service.CreateUnit(id, req)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, unit)
+}
+
+func (h *Handler) DeleteUnit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	unitID, err := strconv.Atoi(r.PathValue("unitId"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid unit ID")
+		return
+	}
+
+	if err := h.service.DeleteUnit(id, unitID); err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetComponents(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	components, err := h.service.GetComponents(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, components)
+}
+
+func (h *Handler) ReplaceComponents(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var components []BundleComponent
+	if err := json.NewDecoder(r.Body).Decode(&components); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var v validation.Validator
+	v.Check(len(components) > 0, "components", validation.CodeRequired, "components cannot be empty")
+	seen := make(map[int]bool)
+	for i, c := range components {
+		field := fmt.Sprintf("[%d].component_id", i)
+		v.Check(c.ComponentID > 0 && c.ComponentID != id, field, validation.CodeInvalid, "invalid component_id")
+		v.Check(!seen[c.ComponentID], field, validation.CodeDuplicate, "duplicate component_id")
+		v.Min(fmt.Sprintf("[%d].quantity", i), c.Quantity, 1)
+		seen[c.ComponentID] = true
+	}
+	if errs := v.Errors(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	result, err := h.service.ReplaceComponents(id, components)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, result)
+}
+
+func (h *Handler) GetRecipe(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	recipe, err := h.service.GetRecipe(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, recipe)
+}
+
+// ReplaceRecipe sets the ingredients of a product. An empty list removes
+// the recipe so the product's own stock is used again.
+func (h *Handler) ReplaceRecipe(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var items []RecipeItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var v validation.Validator
+	seen := make(map[int]bool)
+	for i, item := range items {
+		field := fmt.Sprintf("[%d].ingredient_id", i)
+		v.Check(item.IngredientID > 0 && item.IngredientID != id, field, validation.CodeInvalid, "invalid ingredient_id")
+		v.Check(!seen[item.IngredientID], field, validation.CodeDuplicate, "duplicate ingredient_id")
+		v.Min(fmt.Sprintf("[%d].quantity", i), item.Quantity, 1)
+		seen[item.IngredientID] = true
+	}
+	if errs := v.Errors(); errs != nil {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	recipe, err := h.service.ReplaceRecipe(id, items)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, recipe)
+}
+
+// UploadImage sets the image of a product from the multipart "image" field
+// and generates its thumbnail. A previous image is replaced.
+func (h *Handler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImageSize+1<<20)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Upload a JPEG, PNG or GIF file (max 5 MB) in the image field")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxImageSize+1))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid file")
+		return
+	}
+	if len(data) > MaxImageSize {
+		response.ValidationFailed(w, validation.Field("image", validation.CodeMax, "image cannot be larger than 5 MB"))
+		return
+	}
+
+	image, err := h.service.UploadImage(id, data)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, image)
+}
+
+func (h *Handler) GetImage(w http.ResponseWriter, r *http.Request) {
+	h.serveImage(w, r, false)
+}
+
+func (h *Handler) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	h.serveImage(w, r, true)
+}
+
+// serveImage writes the file behind the stable image URL of a product. The
+// ETag is taken from the storage key, which changes with every upload, so
+// clients can cache the image and revalidate it cheaply.
+func (h *Handler) serveImage(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	f, key, err := h.service.OpenImage(id, thumbnail)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Cache-Control", "no-cache")
+	if etag.NotModified(w, r, `"`+path.Base(key)+`"`) {
+		return
+	}
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(key)))
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, key, time.Time{}, rs)
+		return
+	}
+	io.Copy(w, f)
+}
+
+func (h *Handler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.service.DeleteImage(id); err != nil {
+		response.FromError(w, err)
 		return
 	}
 
@@ -0,0 +1,106 @@
+package product
+
+import (
+	"belajar-go/pkg/imaging"
+	"belajar-go/pkg/validation"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"time"
+)
+
+// MaxImageSize is the largest image accepted by UploadImage.
+const MaxImageSize = 5 << 20
+
+// MaxImageDimension caps the width and height of an uploaded image, so a
+// small file cannot decode into a huge bitmap.
+const MaxImageDimension = 6000
+
+// ThumbnailSize is the longest side of a generated thumbnail.
+const ThumbnailSize = 256
+
+// ArchivedImageRetention is how long an archived product keeps its image,
+// so it is still there if the product is restored.
+const ArchivedImageRetention = 30 * 24 * time.Hour
+
+// orphanGrace keeps the sweep away from files that were just stored and
+// whose product row may not be saved yet.
+const orphanGrace = time.Hour
+
+// imagePrefix is the storage directory of product images.
+const imagePrefix = "products"
+
+// imageTypes maps the accepted content types to file extensions.
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProductImage links to the image of a product. The URLs stay the same
+// when the image is replaced.
+type ProductImage struct {
+	ImageURL     string `json:"image_url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+func newProductImage(productID int) *ProductImage {
+	return &ProductImage{
+		ImageURL:     fmt.Sprintf("/products/%d/image", productID),
+		ThumbnailURL: ThumbnailURL(productID),
+	}
+}
+
+// ThumbnailURL is where the thumbnail of a product with an image is served.
+func ThumbnailURL(productID int) string {
+	return fmt.Sprintf("/products/%d/thumbnail", productID)
+}
+
+// newImageKeys returns storage keys for a new image of a product. Every
+// upload gets new files, so a replaced image is never overwritten while it
+// may still be served.
+func newImageKeys(productID int, ext string) (string, string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	base := fmt.Sprintf("%s/%d/%s", imagePrefix, productID, hex.EncodeToString(b))
+	return base + ext, base + "_thumb.jpg", nil
+}
+
+// decodeImage checks the type and dimensions of an upload and returns its
+// file extension and a JPEG thumbnail.
+func decodeImage(data []byte) (string, []byte, error) {
+	ext, ok := imageTypes[http.DetectContentType(data)]
+	if !ok {
+		return "", nil, validation.Field("image", validation.CodeInvalid, "image must be a JPEG, PNG or GIF file")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, validation.Field("image", validation.CodeInvalid, "image file is damaged")
+	}
+	if config.Width > MaxImageDimension || config.Height > MaxImageDimension {
+		return "", nil, validation.Field("image", validation.CodeMax,
+			fmt.Sprintf("image cannot be larger than %dx%d pixels", MaxImageDimension, MaxImageDimension))
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, validation.Field("image", validation.CodeInvalid, "image file is damaged")
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, imaging.Thumbnail(img, ThumbnailSize), &jpeg.Options{Quality: 85}); err != nil {
+		return "", nil, err
+	}
+
+	return ext, thumb.Bytes(), nil
+}
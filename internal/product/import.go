@@ -0,0 +1,127 @@
+package product
+
+import (
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/validation"
+	"strconv"
+	"strings"
+)
+
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+)
+
+// MaxImportRows caps one import so a single request cannot hold the
+// transaction open for too long.
+const MaxImportRows = 5000
+
+// importHeaders maps the accepted header names, in Indonesian or English,
+// to the import columns.
+var importHeaders = map[string]string{
+	"nama":     "nama",
+	"name":     "nama",
+	"harga":    "harga",
+	"price":    "harga",
+	"stok":     "stok",
+	"stock":    "stok",
+	"kategori": "category",
+	"category": "category",
+	"sku":      "sku",
+	"barcode":  "barcode",
+	"barcodes": "barcode",
+}
+
+// parseImportRows turns the records of an import file into rows. The first
+// record is the header; blank lines are skipped. Problems with a single row
+// are recorded on that row so the whole file can be reported at once.
+func parseImportRows(records [][]string) ([]ImportRow, error) {
+	if len(records) == 0 {
+		return nil, apperror.Validation("file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		if column, ok := importHeaders[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[column] = i
+		}
+	}
+	for _, required := range []string{"nama", "harga"} {
+		if _, ok := columns[required]; !ok {
+			return nil, apperror.Validation("missing column %s", required)
+		}
+	}
+
+	rows := make([]ImportRow, 0, len(records)-1)
+	skus := make(map[string]int)
+	barcodes := make(map[string]int)
+	for i, record := range records[1:] {
+		cell := func(column string) string {
+			j, ok := columns[column]
+			if !ok || j >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[j])
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return nil, apperror.Validation("file has more than %d rows", MaxImportRows)
+		}
+
+		row := ImportRow{Line: i + 2, Nama: cell("nama"), Category: cell("category"), SKU: cell("sku")}
+		var v validation.Validator
+		v.Required("nama", row.Nama)
+		row.Harga = parseImportInt(&v, "harga", cell("harga"))
+		row.Stok = parseImportInt(&v, "stok", cell("stok"))
+
+		row.Barcodes = strings.FieldsFunc(cell("barcode"), func(r rune) bool {
+			return r == ';' || r == ',' || r == ' '
+		})
+		validateBarcodes(&v, row.Barcodes)
+		for _, code := range row.Barcodes {
+			if line, ok := barcodes[code]; ok {
+				v.Add("barcode", validation.CodeDuplicate, "barcode "+code+" is also used on line "+strconv.Itoa(line))
+			}
+			barcodes[code] = row.Line
+		}
+
+		if row.SKU != "" {
+			if line, ok := skus[row.SKU]; ok {
+				v.Add("sku", validation.CodeDuplicate, "sku "+row.SKU+" is also used on line "+strconv.Itoa(line))
+			}
+			skus[row.SKU] = row.Line
+		}
+
+		row.Errors = v.Errors()
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, apperror.Validation("file has no product rows")
+	}
+
+	return rows, nil
+}
+
+// parseImportInt reads a non-negative whole number. Empty cells count as 0
+// and a zero fraction ("3500.00", as some spreadsheets export numbers) is
+// accepted; thousand separators are not, since "3.500" is ambiguous.
+func parseImportInt(v *validation.Validator, field, value string) int {
+	if value == "" {
+		return 0
+	}
+
+	// Only one or two zeros, so "3.000" is rejected instead of read as 3
+	if whole, fraction, ok := strings.Cut(value, "."); ok && (fraction == "0" || fraction == "00") {
+		value = whole
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		v.Add(field, validation.CodeInvalid, field+" must be a whole number")
+		return 0
+	}
+	v.Min(field, n, 0)
+	return n
+}
@@ -0,0 +1,183 @@
+package product
+
+import (
+	"belajar-go/pkg/validation"
+	"reflect"
+	"testing"
+)
+
+func TestParseImportInt(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr string
+	}{
+		{"", 0, ""},
+		{"0", 0, ""},
+		{"3500", 3500, ""},
+		{"3500.0", 3500, ""},
+		{"3500.00", 3500, ""},
+		{"3.000", 0, validation.CodeInvalid},
+		{"3.500", 0, validation.CodeInvalid},
+		{"3500.5", 0, validation.CodeInvalid},
+		{"3,500", 0, validation.CodeInvalid},
+		{"abc", 0, validation.CodeInvalid},
+		{"-1", -1, validation.CodeMin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var v validation.Validator
+			got := parseImportInt(&v, "harga", tt.value)
+			if got != tt.want {
+				t.Errorf("parseImportInt(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+
+			errs := v.Errors()
+			switch {
+			case tt.wantErr == "" && errs != nil:
+				t.Errorf("parseImportInt(%q) errors = %v, want none", tt.value, errs)
+			case tt.wantErr != "" && (len(errs) != 1 || errs[0].Field != "harga" || errs[0].Code != tt.wantErr):
+				t.Errorf("parseImportInt(%q) errors = %+v, want harga %s", tt.value, errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseImportRows(t *testing.T) {
+	// errorFields lists "field:code" of every error on a row
+	errorFields := func(errs validation.Errors) []string {
+		var fields []string
+		for _, fe := range errs {
+			fields = append(fields, fe.Field+":"+fe.Code)
+		}
+		return fields
+	}
+
+	tests := []struct {
+		name       string
+		records    [][]string
+		want       []ImportRow
+		wantErrors [][]string
+		wantErr    bool
+	}{
+		{
+			name:    "empty file",
+			records: nil,
+			wantErr: true,
+		},
+		{
+			name:    "missing harga column",
+			records: [][]string{{"nama", "stok"}, {"Teh", "1"}},
+			wantErr: true,
+		},
+		{
+			name:    "header only",
+			records: [][]string{{"nama", "harga"}},
+			wantErr: true,
+		},
+		{
+			name: "all columns",
+			records: [][]string{
+				{"nama", "harga", "stok", "kategori", "sku", "barcode"},
+				{"Teh Botol", "5000", "24", "Minuman", "TB-01", "4006381333931;96385074"},
+			},
+			want: []ImportRow{
+				{Line: 2, Nama: "Teh Botol", Harga: 5000, Stok: 24, Category: "Minuman", SKU: "TB-01",
+					Barcodes: []string{"4006381333931", "96385074"}},
+			},
+			wantErrors: [][]string{nil},
+		},
+		{
+			name: "english headers, any case and order",
+			records: [][]string{
+				{" SKU ", "Price", "Name", "Category", "Stock"},
+				{"KP-01", "3500.00", "Kopi", "Minuman", ""},
+			},
+			want: []ImportRow{
+				{Line: 2, Nama: "Kopi", Harga: 3500, Stok: 0, Category: "Minuman", SKU: "KP-01", Barcodes: []string{}},
+			},
+			wantErrors: [][]string{nil},
+		},
+		{
+			name: "blank lines are skipped but keep line numbers",
+			records: [][]string{
+				{"nama", "harga"},
+				{"", " "},
+				{"Roti", "8000"},
+				{"Susu"},
+			},
+			want: []ImportRow{
+				{Line: 3, Nama: "Roti", Harga: 8000, Barcodes: []string{}},
+				{Line: 4, Nama: "Susu", Barcodes: []string{}},
+			},
+			wantErrors: [][]string{nil, nil},
+		},
+		{
+			name: "row errors",
+			records: [][]string{
+				{"nama", "harga", "stok", "barcode"},
+				{"", "3.500", "-1", "12345"},
+			},
+			want: []ImportRow{
+				{Line: 2, Nama: "", Harga: 0, Stok: -1, Barcodes: []string{"12345"}},
+			},
+			wantErrors: [][]string{{"nama:required", "harga:invalid", "stok:min", "barcodes[0]:invalid"}},
+		},
+		{
+			name: "duplicate sku and barcode across lines",
+			records: [][]string{
+				{"nama", "harga", "sku", "barcode"},
+				{"Teh", "5000", "TH-01", "96385074"},
+				{"Teh Manis", "6000", "TH-01", "96385074"},
+			},
+			want: []ImportRow{
+				{Line: 2, Nama: "Teh", Harga: 5000, SKU: "TH-01", Barcodes: []string{"96385074"}},
+				{Line: 3, Nama: "Teh Manis", Harga: 6000, SKU: "TH-01", Barcodes: []string{"96385074"}},
+			},
+			wantErrors: [][]string{nil, {"barcode:duplicate", "sku:duplicate"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseImportRows(tt.records)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseImportRows() = %+v, want error", rows)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseImportRows() error = %v", err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("parseImportRows() returned %d rows, want %d", len(rows), len(tt.want))
+			}
+
+			for i := range rows {
+				if got := errorFields(rows[i].Errors); !reflect.DeepEqual(got, tt.wantErrors[i]) {
+					t.Errorf("row %d errors = %v, want %v", i, got, tt.wantErrors[i])
+				}
+				rows[i].Errors = nil
+				if !reflect.DeepEqual(rows[i], tt.want[i]) {
+					t.Errorf("row %d = %+v, want %+v", i, rows[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseImportRowsLimit(t *testing.T) {
+	records := [][]string{{"nama", "harga"}}
+	for i := 0; i <= MaxImportRows; i++ {
+		records = append(records, []string{"Produk", "1000"})
+	}
+
+	if _, err := parseImportRows(records); err == nil {
+		t.Errorf("parseImportRows() with %d rows succeeded, want error", MaxImportRows+1)
+	}
+	if _, err := parseImportRows(records[:MaxImportRows+1]); err != nil {
+		t.Errorf("parseImportRows() with %d rows error = %v", MaxImportRows, err)
+	}
+}
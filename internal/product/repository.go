@@ -1,49 +1,191 @@
 package product
 
 import (
+	"belajar-go/internal/category"
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/database"
+	"belajar-go/pkg/etag"
+	"belajar-go/pkg/patch"
+	"belajar-go/pkg/validation"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type Repository interface {
-	GetAll(nameFilter string) ([]ProductDetail, error)
+	GetAll(filter ProductFilter) ([]ProductDetail, int, error)
 	GetByID(id int) (*ProductDetail, error)
+	GetByBarcode(code string) (*ProductDetail, error)
+	Search(q string, limit int) ([]ProductDetail, error)
+	GetVariants(parentIDs []int) ([]ProductDetail, error)
 	Create(req CreateProductRequest) (*Product, error)
-	Update(id int, req UpdateProductRequest) (*Product, error)
-	Delete(id int) error
-}
-
-type repository struct {
-	db *sql.DB
+	Import(rows []ImportRow, dryRun bool) (*ImportResult, error)
+	CreateVariant(parentID int, req CreateVariantRequest) (*Product, error)
+	GetUnits(productID int) ([]ProductUnit, error)
+	CreateUnit(productID int, req CreateUnitRequest) (*ProductUnit, error)
+	DeleteUnit(productID, unitID int) error
+	GetComponents(bundleID int) ([]BundleComponent, error)
+	ReplaceComponents(bundleID int, components []BundleComponent) ([]BundleComponent, error)
+	GetRecipe(productID int) ([]RecipeItem, error)
+	ReplaceRecipe(productID int, items []RecipeItem) ([]RecipeItem, error)
+	Update(id int, req UpdateProductRequest, ifMatch string) (*Product, error)
+	Patch(id int, p ProductPatch, ifMatch string) (*Product, error)
+	Archive(id int, ifMatch string) error
+	Restore(id int) error
+	CreatePriceSchedule(productID int, req SchedulePriceRequest) (*PriceHistory, error)
+	GetPriceHistory(productID int) ([]PriceHistory, error)
+	GetPriceAt(productID int, at time.Time) (*PriceHistory, error)
+	ApplyScheduledPrices() (int64, error)
+	SetImage(id int, imageKey, thumbnailKey *string) ([]string, error)
+	ClearArchivedImages(archivedBefore time.Time) (int64, error)
+	GetImageKeys() (map[string]bool, error)
+	WithTx(tx *sql.Tx) Repository
 }
 
-func NewRepository(db *sql.DB) Repository {
-	return &repository{db: db}
-}
+// availableStock is the sellable stock of p. The stock of a bundle or
+// recipe product is the number of units its components or ingredients can
+// make.
+const availableStock = `
+			CASE
+				WHEN p.tipe = 'bundle' THEN COALESCE((
+					SELECT MIN(cp.stok / bc.quantity)
+					FROM bundle_components bc
+					JOIN products cp ON bc.component_id = cp.id
+					WHERE bc.bundle_id = p.id
+				), 0)
+				WHEN EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id) THEN (
+					SELECT MIN(ip.stok / ri.quantity)
+					FROM recipe_items ri
+					JOIN products ip ON ri.ingredient_id = ip.id
+					WHERE ri.product_id = p.id
+				)
+				ELSE p.stok
+			END`
 
-func (r *repository) GetAll(nameFilter string) ([]ProductDetail, error) {
-	query := `
+// selectProductDetail selects the columns scanned by ProductDetail.ScanRow.
+const selectProductDetail = `
 		SELECT
 			p.id,
 			p.nama,
-			p.harga,
-			p.stok,
+			p.harga,` + availableStock + `,
+			p.tipe,
+			p.sku,
+			COALESCE((SELECT array_agg(b.barcode ORDER BY b.id) FROM product_barcodes b WHERE b.product_id = p.id), '{}'),
+			p.base_unit,
 			p.category_id,
 			c.name as category_name,
+			p.parent_id,
+			(SELECT json_object_agg(a.name, a.value) FROM product_variant_attributes a WHERE a.product_id = p.id),
+			p.image_key,
+			p.thumbnail_key,
+			p.color,
+			p.icon,
+			p.position,
+			p.favorite_order,
+			p.archived_at,
+			p.version,
 			p.created_at,
 			p.updated_at
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id`
 
+// productSortColumns whitelists the sort keys accepted by GET /products.
+var productSortColumns = map[string]string{
+	"id":         "p.id",
+	"nama":       "p.nama",
+	"harga":      "p.harga",
+	"stok":       availableStock,
+	"created_at": "p.created_at",
+	"updated_at": "p.updated_at",
+	"position":   "p.position",
+}
+
+// productColumns selects the columns scanned by Product.scan.
+const productColumns = "id, nama, harga, stok, tipe, sku, base_unit, category_id, color, icon, version, created_at, updated_at"
+
+type repository struct {
+	db database.Conn
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: database.NewConn(db)}
+}
+
+func (r *repository) WithTx(tx *sql.Tx) Repository {
+	return &repository{db: r.db.WithTx(tx)}
+}
+
+func (r *repository) GetAll(filter ProductFilter) ([]ProductDetail, int, error) {
+	// Variants are listed under their parent, not as top-level products
+	where := " WHERE p.parent_id IS NULL"
 	args := []interface{}{}
 
-	if nameFilter != "" {
-		query += " WHERE p.nama ILIKE $1"
-		args = append(args, "%"+nameFilter+"%")
+	addFilter := func(condition string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	switch filter.Status {
+	case StatusArchived:
+		where += " AND p.archived_at IS NOT NULL"
+	case StatusAll:
+	default:
+		where += " AND p.archived_at IS NULL"
+	}
+
+	if filter.Name != "" {
+		addFilter("p.nama ILIKE $%d", "%"+filter.Name+"%")
+	}
+	if filter.CategoryID != nil {
+		// Products of subcategories belong to the category too
+		addFilter("p.category_id IN ("+category.SubtreeIDs+")", *filter.CategoryID)
+	}
+	if filter.MinHarga != nil {
+		addFilter("p.harga >= $%d", *filter.MinHarga)
+	}
+	if filter.MaxHarga != nil {
+		addFilter("p.harga <= $%d", *filter.MaxHarga)
+	}
+	if filter.MinStok != nil {
+		addFilter(availableStock+" >= $%d", *filter.MinStok)
+	}
+	if filter.MaxStok != nil {
+		addFilter(availableStock+" <= $%d", *filter.MaxStok)
+	}
+	if filter.UpdatedSince != nil {
+		addFilter("p.updated_at >= $%d", *filter.UpdatedSince)
 	}
 
-	query += " ORDER BY p.id ASC"
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM products p"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
 
+	query := selectProductDetail + where + filter.OrderBy(productSortColumns, "p.id")
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, filter.PerPage, filter.Offset())
+
+	products, err := r.getMany(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
+}
+
+func (r *repository) GetVariants(parentIDs []int) ([]ProductDetail, error) {
+	query := selectProductDetail + `
+		WHERE p.parent_id = ANY($1) AND p.archived_at IS NULL
+		ORDER BY p.parent_id ASC, p.id ASC`
+
+	return r.getMany(query, pq.Array(parentIDs))
+}
+
+func (r *repository) getMany(query string, args ...interface{}) ([]ProductDetail, error) {
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -59,33 +201,50 @@ func (r *repository) GetAll(nameFilter string) ([]ProductDetail, error) {
 		products = append(products, prod)
 	}
 
-	return products, nil
+	return products, rows.Err()
 }
 
 func (r *repository) GetByID(id int) (*ProductDetail, error) {
-	query := `
-		SELECT
-			p.id,
-			p.nama,
-			p.harga,
-			p.stok,
-			p.category_id,
-			c.name as category_name,
-			p.created_at,
-			p.updated_at
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1
-	`
+	return r.getOne(selectProductDetail+" WHERE p.id = $1", id)
+}
+
+func (r *repository) GetByBarcode(code string) (*ProductDetail, error) {
+	query := selectProductDetail + `
+		WHERE p.id = (SELECT product_id FROM product_barcodes WHERE barcode = $1)
+			AND p.archived_at IS NULL`
 
-	rows, err := r.db.Query(query, id)
+	return r.getOne(query, code)
+}
+
+// Search ranks products by exact SKU/barcode match first, then by
+// full-text prefix rank plus trigram word similarity, so "indomi goreng"
+// still finds "Indomie Goreng".
+func (r *repository) Search(q string, limit int) ([]ProductDetail, error) {
+	query := selectProductDetail + `
+		WHERE p.archived_at IS NULL
+			AND (to_tsvector('simple', p.nama) @@ to_tsquery('simple', $1)
+				OR $2 <% p.nama
+				OR p.sku ILIKE $3
+				OR EXISTS (SELECT 1 FROM product_barcodes b WHERE b.product_id = p.id AND b.barcode LIKE $3))
+		ORDER BY
+			(UPPER(p.sku) = UPPER($2) OR EXISTS (SELECT 1 FROM product_barcodes b WHERE b.product_id = p.id AND b.barcode = $2)) DESC,
+			ts_rank(to_tsvector('simple', p.nama), to_tsquery('simple', $1)) + word_similarity($2, p.nama) DESC,
+			p.nama ASC,
+			p.id ASC
+		LIMIT $4`
+
+	return r.getMany(query, prefixQuery(q), q, likePrefix(q), limit)
+}
+
+func (r *repository) getOne(query string, args ...interface{}) (*ProductDetail, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("product not found")
+		return nil, apperror.NotFound("product not found")
 	}
 
 	var prod ProductDetail
@@ -97,49 +256,702 @@ func (r *repository) GetByID(id int) (*ProductDetail, error) {
 }
 
 func (r *repository) Create(req CreateProductRequest) (*Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
 	query := `
-		INSERT INTO products (nama, harga, stok, category_id)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, nama, harga, stok, category_id, created_at, updated_at
-	`
+		INSERT INTO products (nama, harga, stok, tipe, sku, base_unit, category_id, color, icon)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + productColumns
 
 	var prod Product
-	err := r.db.QueryRow(query, req.Nama, req.Harga, req.Stok, req.CategoryID).Scan(
-		&prod.ID, &prod.Nama, &prod.Harga, &prod.Stok, &prod.CategoryID, &prod.CreatedAt, &prod.UpdatedAt,
-	)
+	err = prod.scan(tx.QueryRow(query, req.Nama, req.Harga, req.Stok, req.Tipe, req.SKU, req.BaseUnit, req.CategoryID, req.Color, req.Icon))
 	if err != nil {
+		return nil, constraintError(err)
+	}
+
+	if prod.Barcodes, err = replaceBarcodes(tx, prod.ID, req.Barcodes); err != nil {
+		return nil, err
+	}
+
+	if err := RecordPrice(tx, prod.ID, prod.Harga, PriceSourceManual); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Commit(tx); err != nil {
 		return nil, err
 	}
 
 	return &prod, nil
 }
 
-func (r *repository) Update(id int, req UpdateProductRequest) (*Product, error) {
+// Import applies the rows in one transaction, each behind a savepoint so a
+// failing row is reported without hiding the errors of later rows. The
+// transaction is only committed when every row succeeded and dryRun is
+// false.
+func (r *repository) Import(rows []ImportRow, dryRun bool) (*ImportResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	result := &ImportResult{DryRun: dryRun, Valid: true, CategoriesCreated: []string{}, Rows: rows}
+	categories := make(map[string]int)
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Errors != nil {
+			result.Valid = false
+			continue
+		}
+
+		if _, err := tx.Exec("SAVEPOINT import_row"); err != nil {
+			return nil, err
+		}
+
+		newCategory, err := importRow(tx, row, categories)
+		if err != nil {
+			var verrs validation.Errors
+			var appErr *apperror.Error
+			switch {
+			case errors.As(err, &verrs):
+				row.Errors = verrs
+			case errors.As(err, &appErr):
+				row.Errors = validation.Field("row", validation.CodeInvalid, appErr.Message)
+			default:
+				return nil, err
+			}
+			row.Action, row.ProductID = "", nil
+			result.Valid = false
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT import_row"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if _, err := tx.Exec("RELEASE SAVEPOINT import_row"); err != nil {
+			return nil, err
+		}
+		if newCategory != 0 {
+			categories[strings.ToLower(row.Category)] = newCategory
+			result.CategoriesCreated = append(result.CategoriesCreated, row.Category)
+		}
+		if row.Action == ImportActionCreate {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if !result.Valid || dryRun {
+		return result, nil
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importRow creates the product, or updates the one with the same SKU. It
+// returns the ID of the category it had to create, if any; categories is
+// only updated by the caller once the row is known to have succeeded.
+func importRow(tx *sql.Tx, row *ImportRow, categories map[string]int) (int, error) {
+	var categoryID *int
+	newCategory := 0
+	if row.Category != "" {
+		id, ok := categories[strings.ToLower(row.Category)]
+		if !ok {
+			// Prefer an active category when an archived one has the same name
+			err := tx.QueryRow(
+				"SELECT id FROM categories WHERE LOWER(name) = LOWER($1) ORDER BY archived_at IS NOT NULL, id LIMIT 1",
+				row.Category,
+			).Scan(&id)
+			if err == sql.ErrNoRows {
+				err = tx.QueryRow("INSERT INTO categories (name) VALUES ($1) RETURNING id", row.Category).Scan(&id)
+				newCategory = id
+			}
+			if err != nil {
+				return 0, category.ConstraintError(err)
+			}
+			if newCategory == 0 {
+				categories[strings.ToLower(row.Category)] = id
+			}
+		}
+		categoryID = &id
+	}
+
+	var sku *string
+	if row.SKU != "" {
+		sku = &row.SKU
+	}
+
+	var id, oldHarga int
+	var tipe string
+	var archived bool
+	err := tx.QueryRow("SELECT id, tipe, harga, archived_at IS NOT NULL FROM products WHERE sku = $1 FOR UPDATE", sku).
+		Scan(&id, &tipe, &oldHarga, &archived)
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.QueryRow(
+			`INSERT INTO products (nama, harga, stok, tipe, sku, base_unit, category_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			row.Nama, row.Harga, row.Stok, TypeStandard, sku, DefaultBaseUnit, categoryID,
+		).Scan(&id)
+		if err != nil {
+			return 0, constraintError(err)
+		}
+		row.Action = ImportActionCreate
+	case err != nil:
+		return 0, err
+	case archived:
+		return 0, apperror.Conflict("sku %s belongs to an archived product, restore it before importing", row.SKU)
+	case tipe != TypeStandard:
+		return 0, apperror.Validation("sku %s belongs to a %s product, which cannot be imported", row.SKU, tipe)
+	default:
+		_, err = tx.Exec(
+			"UPDATE products SET nama = $1, harga = $2, stok = $3, category_id = $4 WHERE id = $5",
+			row.Nama, row.Harga, row.Stok, categoryID, id,
+		)
+		if err != nil {
+			return 0, constraintError(err)
+		}
+		row.Action = ImportActionUpdate
+	}
+	row.ProductID = &id
+
+	// An update without barcodes keeps the barcodes the product already has
+	if row.Action == ImportActionCreate || len(row.Barcodes) > 0 {
+		if _, err := replaceBarcodes(tx, id, row.Barcodes); err != nil {
+			return 0, err
+		}
+	}
+
+	if row.Action == ImportActionCreate || row.Harga != oldHarga {
+		if err := RecordPrice(tx, id, row.Harga, PriceSourceManual); err != nil {
+			return 0, err
+		}
+	}
+
+	return newCategory, nil
+}
+
+func (r *repository) CreateVariant(parentID int, req CreateVariantRequest) (*Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	var parentName, parentType, parentBaseUnit string
+	var parentCategoryID *int
+	var grandParentID *int
+	err = tx.QueryRow("SELECT nama, tipe, base_unit, category_id, parent_id FROM products WHERE id = $1 FOR UPDATE", parentID).
+		Scan(&parentName, &parentType, &parentBaseUnit, &parentCategoryID, &grandParentID)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grandParentID != nil {
+		return nil, apperror.Validation("cannot add a variant to a variant")
+	}
+	if parentType != TypeStandard {
+		return nil, apperror.Validation("only standard products can have variants")
+	}
+
+	query := `
+		INSERT INTO products (nama, harga, stok, tipe, sku, base_unit, category_id, parent_id, variant_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + productColumns
+
+	var prod Product
+	err = prod.scan(tx.QueryRow(query, variantName(parentName, req.Options), req.Harga, req.Stok, TypeStandard, req.SKU,
+		parentBaseUnit, parentCategoryID, parentID, variantKey(req.Options)))
+	if err != nil {
+		return nil, constraintError(err)
+	}
+
+	for name, value := range req.Options {
+		_, err := tx.Exec("INSERT INTO product_variant_attributes (product_id, name, value) VALUES ($1, $2, $3)",
+			prod.ID, name, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if prod.Barcodes, err = replaceBarcodes(tx, prod.ID, req.Barcodes); err != nil {
+		return nil, err
+	}
+
+	if err := RecordPrice(tx, prod.ID, prod.Harga, PriceSourceManual); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	return &prod, nil
+}
+
+func (r *repository) Update(id int, req UpdateProductRequest, ifMatch string) (*Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	oldHarga, err := lockForUpdate(tx, id, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		UPDATE products
-		SET nama = $1, harga = $2, stok = $3, category_id = $4
-		WHERE id = $5
-		RETURNING id, nama, harga, stok, category_id, created_at, updated_at
-	`
+		SET nama = $1, harga = $2, stok = $3, sku = $4, base_unit = $5, category_id = $6, color = $7, icon = $8
+		WHERE id = $9
+		RETURNING ` + productColumns
 
 	var prod Product
-	err := r.db.QueryRow(query, req.Nama, req.Harga, req.Stok, req.CategoryID, id).Scan(
-		&prod.ID, &prod.Nama, &prod.Harga, &prod.Stok, &prod.CategoryID, &prod.CreatedAt, &prod.UpdatedAt,
+	err = prod.scan(tx.QueryRow(query, req.Nama, req.Harga, req.Stok, req.SKU, req.BaseUnit, req.CategoryID, req.Color, req.Icon, id))
+	if err != nil {
+		return nil, constraintError(err)
+	}
+
+	if prod.Barcodes, err = replaceBarcodes(tx, prod.ID, req.Barcodes); err != nil {
+		return nil, err
+	}
+
+	if prod.Harga != oldHarga {
+		if err := RecordPrice(tx, prod.ID, prod.Harga, PriceSourceManual); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	return &prod, nil
+}
+
+// Patch updates only the columns present in the patch.
+func (r *repository) Patch(id int, p ProductPatch, ifMatch string) (*Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	oldHarga, err := lockForUpdate(tx, id, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	returning := " RETURNING " + productColumns
+	query := "SELECT " + productColumns + " FROM products WHERE id = $1"
+	args := []interface{}{id}
+	if len(p.Columns) > 0 {
+		var sets string
+		sets, args = patch.Assignments(p.Columns)
+		query = fmt.Sprintf("UPDATE products SET %s WHERE id = $%d", sets, len(args)+1) + returning
+		args = append(args, id)
+	} else if p.Barcodes != nil {
+		// Barcodes live in their own table; touch the row so its version moves
+		query = "UPDATE products SET version = version WHERE id = $1" + returning
+	}
+
+	var prod Product
+	err = prod.scan(tx.QueryRow(query, args...))
+	if err != nil {
+		return nil, constraintError(err)
+	}
+
+	if p.Barcodes != nil {
+		if prod.Barcodes, err = replaceBarcodes(tx, prod.ID, *p.Barcodes); err != nil {
+			return nil, err
+		}
+	} else {
+		err = tx.QueryRow("SELECT COALESCE(array_agg(barcode ORDER BY id), '{}') FROM product_barcodes WHERE product_id = $1", id).
+			Scan(pq.Array(&prod.Barcodes))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if prod.Harga != oldHarga {
+		if err := RecordPrice(tx, prod.ID, prod.Harga, PriceSourceManual); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	return &prod, nil
+}
+
+// lockForUpdate locks the product row and checks the If-Match tag against
+// its version, so concurrent edits cannot overwrite each other. It returns
+// the current harga.
+func lockForUpdate(tx *sql.Tx, id int, ifMatch string) (int, error) {
+	var harga, version int
+	err := tx.QueryRow("SELECT harga, version FROM products WHERE id = $1 FOR UPDATE", id).Scan(&harga, &version)
+	if err == sql.ErrNoRows {
+		return 0, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !etag.MatchesStrong(ifMatch, etag.Version(version)) {
+		return 0, etag.ErrPreconditionFailed
+	}
+
+	return harga, nil
+}
+
+// replaceBarcodes sets the barcodes of a product to exactly the given list.
+func replaceBarcodes(tx *sql.Tx, productID int, barcodes []string) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = $1", productID); err != nil {
+		return nil, err
+	}
+
+	for _, code := range barcodes {
+		_, err := tx.Exec("INSERT INTO product_barcodes (product_id, barcode) VALUES ($1, $2)", productID, code)
+		if err != nil {
+			return nil, constraintError(err)
+		}
+	}
+
+	if barcodes == nil {
+		barcodes = []string{}
+	}
+	return barcodes, nil
+}
+
+// constraintError turns unique violations on SKU and barcode into readable
+// errors, and a missing category into a field error on category_id.
+func constraintError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" && pqErr.Constraint == "products_category_id_fkey" {
+		return validation.Field("category_id", validation.CodeNotFound, "category not found")
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "products_sku_key":
+			return apperror.Conflict("sku already exists")
+		case "product_barcodes_barcode_key":
+			return apperror.Conflict("barcode already exists")
+		case "products_parent_id_variant_key_key":
+			return apperror.Conflict("a variant with these options already exists")
+		case "product_units_product_id_name_key":
+			return apperror.Conflict("unit already exists for this product")
+		}
+	}
+	return err
+}
+
+// RecordPrice appends an open-ended price history row effective now, so the
+// scheduler keeps the price set directly on the product.
+func RecordPrice(tx *sql.Tx, productID, harga int, source string) error {
+	_, err := tx.Exec(
+		"INSERT INTO product_price_history (product_id, harga, effective_from, source) VALUES ($1, $2, NOW(), $3)",
+		productID, harga, source,
 	)
+	return err
+}
+
+// Archive hides a product and its variants from listings and checkout.
+// Rows are kept so past transactions still resolve their products.
+func (r *repository) Archive(id int, ifMatch string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer r.db.Rollback(tx)
+
+	if _, err := lockForUpdate(tx, id, ifMatch); err != nil {
+		return err
+	}
+
+	query := `UPDATE products SET archived_at = NOW() WHERE (id = $1 OR parent_id = $1) AND archived_at IS NULL`
+	if _, err := tx.Exec(query, id); err != nil {
+		return err
+	}
+
+	return r.db.Commit(tx)
+}
+
+// Restore brings an archived product back with the variants archived along
+// with it, which share its archived_at; variants archived on their own
+// before stay archived. A variant can only be restored while its parent is
+// active.
+func (r *repository) Restore(id int) error {
+	var parentArchived sql.NullBool
+	err := r.db.QueryRow(`
+		SELECT parent.archived_at IS NOT NULL
+		FROM products p
+		LEFT JOIN products parent ON p.parent_id = parent.id
+		WHERE p.id = $1`, id).Scan(&parentArchived)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("product not found")
+		return apperror.NotFound("product not found")
 	}
+	if err != nil {
+		return err
+	}
+	if parentArchived.Valid && parentArchived.Bool {
+		return apperror.Conflict("restore the parent product first")
+	}
+
+	_, err = r.db.Exec(`
+		UPDATE products SET archived_at = NULL
+		WHERE archived_at IS NOT NULL
+			AND (id = $1 OR (parent_id = $1 AND archived_at = (SELECT archived_at FROM products WHERE id = $1)))`, id)
+	return err
+}
+
+func (r *repository) CreatePriceSchedule(productID int, req SchedulePriceRequest) (*PriceHistory, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
 	if err != nil {
 		return nil, err
 	}
+	if !exists {
+		return nil, apperror.NotFound("product not found")
+	}
 
-	return &prod, nil
+	query := `
+		INSERT INTO product_price_history (product_id, harga, effective_from, effective_to, source)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, product_id, harga, effective_from, effective_to, source, created_at
+	`
+
+	var h PriceHistory
+	err = r.db.QueryRow(query, productID, req.Harga, req.EffectiveFrom, req.EffectiveTo, PriceSourceScheduled).Scan(
+		&h.ID, &h.ProductID, &h.Harga, &h.EffectiveFrom, &h.EffectiveTo, &h.Source, &h.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+func (r *repository) GetPriceHistory(productID int) ([]PriceHistory, error) {
+	query := `
+		SELECT id, product_id, harga, effective_from, effective_to, source, created_at
+		FROM product_price_history
+		WHERE product_id = $1
+		ORDER BY effective_from DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]PriceHistory, 0)
+	for rows.Next() {
+		var h PriceHistory
+		if err := rows.Scan(&h.ID, &h.ProductID, &h.Harga, &h.EffectiveFrom, &h.EffectiveTo, &h.Source, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, nil
+}
+
+func (r *repository) GetPriceAt(productID int, at time.Time) (*PriceHistory, error) {
+	query := `
+		SELECT id, product_id, harga, effective_from, effective_to, source, created_at
+		FROM product_price_history
+		WHERE product_id = $1
+			AND effective_from <= $2
+			AND (effective_to IS NULL OR effective_to > $2)
+		ORDER BY effective_from DESC, id DESC
+		LIMIT 1
+	`
+
+	var h PriceHistory
+	err := r.db.QueryRow(query, productID, at).Scan(
+		&h.ID, &h.ProductID, &h.Harga, &h.EffectiveFrom, &h.EffectiveTo, &h.Source, &h.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("no price recorded for product at %s", at.Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// ApplyScheduledPrices sets products.harga to the price effective now for
+// every product whose current price differs. It returns the number of
+// products updated.
+func (r *repository) ApplyScheduledPrices() (int64, error) {
+	query := `
+		UPDATE products p
+		SET harga = h.harga
+		FROM (
+			SELECT DISTINCT ON (product_id) product_id, harga
+			FROM product_price_history
+			WHERE effective_from <= NOW()
+				AND (effective_to IS NULL OR effective_to > NOW())
+			ORDER BY product_id, effective_from DESC, id DESC
+		) h
+		WHERE p.id = h.product_id AND p.harga <> h.harga
+	`
+
+	result, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// SetImage sets the image of a product, or removes it when the keys are
+// nil, and returns the keys it replaced so their files can be deleted.
+func (r *repository) SetImage(id int, imageKey, thumbnailKey *string) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	var oldImage, oldThumbnail *string
+	err = tx.QueryRow("SELECT image_key, thumbnail_key FROM products WHERE id = $1 FOR UPDATE", id).
+		Scan(&oldImage, &oldThumbnail)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE products SET image_key = $1, thumbnail_key = $2 WHERE id = $3", imageKey, thumbnailKey, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	replaced := make([]string, 0, 2)
+	for _, key := range []*string{oldImage, oldThumbnail} {
+		if key != nil {
+			replaced = append(replaced, *key)
+		}
+	}
+	return replaced, nil
+}
+
+// ClearArchivedImages removes the image of products archived before the
+// given time. Their files are left to the orphan sweep.
+func (r *repository) ClearArchivedImages(archivedBefore time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE products SET image_key = NULL, thumbnail_key = NULL
+		WHERE archived_at < $1 AND (image_key IS NOT NULL OR thumbnail_key IS NOT NULL)`, archivedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetImageKeys returns every storage key a product refers to.
+func (r *repository) GetImageKeys() (map[string]bool, error) {
+	rows, err := r.db.Query(`
+		SELECT image_key FROM products WHERE image_key IS NOT NULL
+		UNION ALL
+		SELECT thumbnail_key FROM products WHERE thumbnail_key IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys[key] = true
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *repository) GetUnits(productID int) ([]ProductUnit, error) {
+	query := `
+		SELECT id, product_id, name, conversion, harga
+		FROM product_units
+		WHERE product_id = $1
+		ORDER BY conversion ASC, id ASC
+	`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := make([]ProductUnit, 0)
+	for rows.Next() {
+		var u ProductUnit
+		if err := rows.Scan(&u.ID, &u.ProductID, &u.Name, &u.Conversion, &u.Harga); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+
+	return units, nil
 }
 
-func (r *repository) Delete(id int) error {
-	query := `DELETE FROM products WHERE id = $1`
+func (r *repository) CreateUnit(productID int, req CreateUnitRequest) (*ProductUnit, error) {
+	var baseUnit string
+	err := r.db.QueryRow("SELECT base_unit FROM products WHERE id = $1", productID).Scan(&baseUnit)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == baseUnit {
+		return nil, apperror.Validation("unit %s is already the base unit", req.Name)
+	}
+
+	query := `
+		INSERT INTO product_units (product_id, name, conversion, harga)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, product_id, name, conversion, harga
+	`
 
-	result, err := r.db.Exec(query, id)
+	var u ProductUnit
+	err = r.db.QueryRow(query, productID, req.Name, req.Conversion, req.Harga).Scan(
+		&u.ID, &u.ProductID, &u.Name, &u.Conversion, &u.Harga,
+	)
+	if err != nil {
+		return nil, constraintError(err)
+	}
+
+	return &u, nil
+}
+
+func (r *repository) DeleteUnit(productID, unitID int) error {
+	result, err := r.db.Exec("DELETE FROM product_units WHERE id = $1 AND product_id = $2", unitID, productID)
 	if err != nil {
 		return err
 	}
@@ -150,8 +962,187 @@ func (r *repository) Delete(id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return apperror.NotFound("unit not found")
 	}
 
 	return nil
 }
+
+func (r *repository) GetComponents(bundleID int) ([]BundleComponent, error) {
+	query := `
+		SELECT bc.component_id, p.nama, bc.quantity
+		FROM bundle_components bc
+		JOIN products p ON bc.component_id = p.id
+		WHERE bc.bundle_id = $1
+		ORDER BY bc.component_id ASC
+	`
+
+	rows, err := r.db.Query(query, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := make([]BundleComponent, 0)
+	for rows.Next() {
+		var c BundleComponent
+		if err := rows.Scan(&c.ComponentID, &c.Nama, &c.Quantity); err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+
+	return components, nil
+}
+
+func (r *repository) ReplaceComponents(bundleID int, components []BundleComponent) ([]BundleComponent, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	var tipe string
+	err = tx.QueryRow("SELECT tipe FROM products WHERE id = $1 FOR UPDATE", bundleID).Scan(&tipe)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tipe != TypeBundle {
+		return nil, apperror.Validation("product is not a bundle")
+	}
+
+	if _, err := tx.Exec("DELETE FROM bundle_components WHERE bundle_id = $1", bundleID); err != nil {
+		return nil, err
+	}
+
+	for i, c := range components {
+		var componentType string
+		var hasVariants, hasRecipe bool
+		err := tx.QueryRow(`
+			SELECT nama, tipe, EXISTS(SELECT 1 FROM products v WHERE v.parent_id = p.id),
+				EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id)
+			FROM products p WHERE p.id = $1`, c.ComponentID).
+			Scan(&components[i].Nama, &componentType, &hasVariants, &hasRecipe)
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("component product id %d not found", c.ComponentID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if componentType != TypeStandard || hasVariants || hasRecipe {
+			return nil, apperror.Validation("component %s must be a standard product without variants or a recipe", components[i].Nama)
+		}
+
+		_, err = tx.Exec("INSERT INTO bundle_components (bundle_id, component_id, quantity) VALUES ($1, $2, $3)",
+			bundleID, c.ComponentID, c.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	return components, nil
+}
+
+func (r *repository) GetRecipe(productID int) ([]RecipeItem, error) {
+	query := `
+		SELECT ri.ingredient_id, p.nama, p.base_unit, ri.quantity
+		FROM recipe_items ri
+		JOIN products p ON ri.ingredient_id = p.id
+		WHERE ri.product_id = $1
+		ORDER BY ri.ingredient_id ASC
+	`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]RecipeItem, 0)
+	for rows.Next() {
+		var item RecipeItem
+		if err := rows.Scan(&item.IngredientID, &item.Nama, &item.BaseUnit, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *repository) ReplaceRecipe(productID int, items []RecipeItem) ([]RecipeItem, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer r.db.Rollback(tx)
+
+	var tipe string
+	err = tx.QueryRow("SELECT tipe FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&tipe)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tipe != TypeStandard {
+		return nil, apperror.Validation("only standard products can have a recipe")
+	}
+
+	// Recipes are one level deep and bundles deduct component stock directly
+	var isIngredient, isComponent bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM recipe_items WHERE ingredient_id = $1),
+			EXISTS(SELECT 1 FROM bundle_components WHERE component_id = $1)`, productID).
+		Scan(&isIngredient, &isComponent)
+	if err != nil {
+		return nil, err
+	}
+	if isIngredient && len(items) > 0 {
+		return nil, apperror.Validation("product is an ingredient of another recipe and cannot have its own recipe")
+	}
+	if isComponent && len(items) > 0 {
+		return nil, apperror.Validation("product is a bundle component and cannot have a recipe")
+	}
+
+	if _, err := tx.Exec("DELETE FROM recipe_items WHERE product_id = $1", productID); err != nil {
+		return nil, err
+	}
+
+	for i, item := range items {
+		var ingredientType string
+		var hasRecipe bool
+		err := tx.QueryRow(`
+			SELECT nama, base_unit, tipe, EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id)
+			FROM products p WHERE p.id = $1`, item.IngredientID).
+			Scan(&items[i].Nama, &items[i].BaseUnit, &ingredientType, &hasRecipe)
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("ingredient product id %d not found", item.IngredientID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ingredientType != TypeStandard || hasRecipe {
+			return nil, apperror.Validation("ingredient %s must be a standard product without its own recipe", items[i].Nama)
+		}
+
+		_, err = tx.Exec("INSERT INTO recipe_items (product_id, ingredient_id, quantity) VALUES ($1, $2, $3)",
+			productID, item.IngredientID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.db.Commit(tx); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,57 @@
+package product
+
+import (
+	"log"
+	"time"
+)
+
+// StartPriceScheduler applies pending scheduled price changes every
+// interval until the returned stop function is called.
+func StartPriceScheduler(service Service, interval time.Duration) (stop func()) {
+	return runEvery(interval, func() {
+		updated, err := service.ApplyScheduledPrices()
+		if err != nil {
+			log.Printf("Price scheduler: %v", err)
+			return
+		}
+		if updated > 0 {
+			log.Printf("Price scheduler: updated price of %d product(s)", updated)
+		}
+	})
+}
+
+// StartImageSweeper deletes orphaned product images every interval until
+// the returned stop function is called.
+func StartImageSweeper(service Service, interval time.Duration) (stop func()) {
+	return runEvery(interval, func() {
+		deleted, err := service.SweepImages()
+		if err != nil {
+			log.Printf("Image sweeper: %v", err)
+		}
+		if deleted > 0 {
+			log.Printf("Image sweeper: deleted %d orphaned file(s)", deleted)
+		}
+	})
+}
+
+// runEvery calls run right away and then every interval until the returned
+// stop function is called.
+func runEvery(interval time.Duration, run func()) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		run()
+		for {
+			select {
+			case <-ticker.C:
+				run()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
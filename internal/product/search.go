@@ -0,0 +1,33 @@
+package product
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+)
+
+// prefixQuery turns what the cashier typed into a to_tsquery expression
+// where every word is a prefix ("indomi gor" -> "indomi:* & gor:*"). Only
+// letters and digits are kept so the result is always valid tsquery syntax.
+func prefixQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// likePrefix escapes LIKE wildcards in q and appends one, for SKU and
+// barcode prefix matches.
+func likePrefix(q string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(q) + "%"
+}
@@ -1,37 +1,374 @@
 package product
 
+import (
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/storage"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"time"
+)
+
 type Service interface {
-	GetAll(nameFilter string) ([]ProductDetail, error)
+	GetAll(filter ProductFilter) ([]ProductDetail, int, error)
 	GetByID(id int) (*ProductDetail, error)
+	GetByBarcode(code string) (*ProductDetail, error)
+	Search(q string, limit int) ([]ProductDetail, error)
 	Create(req CreateProductRequest) (*Product, error)
-	Update(id int, req UpdateProductRequest) (*Product, error)
-	Delete(id int) error
+	Import(records [][]string, dryRun bool) (*ImportResult, error)
+	CreateVariant(parentID int, req CreateVariantRequest) (*Product, error)
+	GetUnits(productID int) ([]ProductUnit, error)
+	CreateUnit(productID int, req CreateUnitRequest) (*ProductUnit, error)
+	DeleteUnit(productID, unitID int) error
+	GetComponents(bundleID int) ([]BundleComponent, error)
+	ReplaceComponents(bundleID int, components []BundleComponent) ([]BundleComponent, error)
+	GetRecipe(productID int) ([]RecipeItem, error)
+	ReplaceRecipe(productID int, items []RecipeItem) ([]RecipeItem, error)
+	Update(id int, req UpdateProductRequest, ifMatch string) (*Product, error)
+	Patch(id int, p ProductPatch, ifMatch string) (*Product, error)
+	Archive(id int, ifMatch string) error
+	Restore(id int) (*ProductDetail, error)
+	SchedulePrice(productID int, req SchedulePriceRequest) (*PriceHistory, error)
+	GetPriceHistory(productID int) ([]PriceHistory, error)
+	GetPriceAt(productID int, at time.Time) (*PriceAt, error)
+	ApplyScheduledPrices() (int64, error)
+	UploadImage(id int, data []byte) (*ProductImage, error)
+	OpenImage(id int, thumbnail bool) (io.ReadCloser, string, error)
+	DeleteImage(id int) error
+	SweepImages() (int, error)
 }
 
 type service struct {
-	repo Repository
+	repo   Repository
+	images storage.Storage
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, images storage.Storage) Service {
+	return &service{repo: repo, images: images}
 }
 
-func (s *service) GetAll(nameFilter string) ([]ProductDetail, error) {
-	return s.repo.GetAll(nameFilter)
+func (s *service) GetAll(filter ProductFilter) ([]ProductDetail, int, error) {
+	products, total, err := s.repo.GetAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.attachVariants(products); err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
 }
 
 func (s *service) GetByID(id int) (*ProductDetail, error) {
-	return s.repo.GetByID(id)
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.ParentID == nil {
+		products := []ProductDetail{*product}
+		if err := s.attachVariants(products); err != nil {
+			return nil, err
+		}
+		product = &products[0]
+	}
+
+	product.Units, err = s.repo.GetUnits(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Tipe == TypeBundle {
+		product.Components, err = s.repo.GetComponents(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	product.Recipe, err = s.repo.GetRecipe(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// attachVariants loads the variants of the given products in one query and
+// fills in their variant matrix.
+func (s *service) attachVariants(products []ProductDetail) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	variants, err := s.repo.GetVariants(ids)
+	if err != nil {
+		return err
+	}
+
+	byParent := make(map[int][]ProductDetail)
+	for _, v := range variants {
+		byParent[*v.ParentID] = append(byParent[*v.ParentID], v)
+	}
+
+	for i := range products {
+		if vs, ok := byParent[products[i].ID]; ok {
+			products[i].Variants = vs
+			products[i].VariantOptions = variantMatrix(vs)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) GetByBarcode(code string) (*ProductDetail, error) {
+	return s.repo.GetByBarcode(code)
+}
+
+func (s *service) Search(q string, limit int) ([]ProductDetail, error) {
+	return s.repo.Search(q, limit)
 }
 
 func (s *service) Create(req CreateProductRequest) (*Product, error) {
 	return s.repo.Create(req)
 }
 
-func (s *service) Update(id int, req UpdateProductRequest) (*Product, error) {
-	return s.repo.Update(id, req)
+// Import upserts products from the records of a CSV or XLSX file, whose
+// first record is the header.
+func (s *service) Import(records [][]string, dryRun bool) (*ImportResult, error) {
+	rows, err := parseImportRows(records)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Import(rows, dryRun)
+}
+
+func (s *service) CreateVariant(parentID int, req CreateVariantRequest) (*Product, error) {
+	return s.repo.CreateVariant(parentID, req)
+}
+
+func (s *service) GetUnits(productID int) ([]ProductUnit, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetUnits(productID)
+}
+
+func (s *service) CreateUnit(productID int, req CreateUnitRequest) (*ProductUnit, error) {
+	return s.repo.CreateUnit(productID, req)
+}
+
+func (s *service) DeleteUnit(productID, unitID int) error {
+	return s.repo.DeleteUnit(productID, unitID)
+}
+
+func (s *service) GetComponents(bundleID int) ([]BundleComponent, error) {
+	if _, err := s.repo.GetByID(bundleID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetComponents(bundleID)
+}
+
+func (s *service) ReplaceComponents(bundleID int, components []BundleComponent) ([]BundleComponent, error) {
+	return s.repo.ReplaceComponents(bundleID, components)
+}
+
+func (s *service) GetRecipe(productID int) ([]RecipeItem, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetRecipe(productID)
+}
+
+func (s *service) ReplaceRecipe(productID int, items []RecipeItem) ([]RecipeItem, error) {
+	return s.repo.ReplaceRecipe(productID, items)
+}
+
+func (s *service) Update(id int, req UpdateProductRequest, ifMatch string) (*Product, error) {
+	return s.repo.Update(id, req, ifMatch)
+}
+
+func (s *service) Patch(id int, p ProductPatch, ifMatch string) (*Product, error) {
+	return s.repo.Patch(id, p, ifMatch)
+}
+
+func (s *service) Archive(id int, ifMatch string) error {
+	return s.repo.Archive(id, ifMatch)
+}
+
+func (s *service) Restore(id int) (*ProductDetail, error) {
+	if err := s.repo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+func (s *service) SchedulePrice(productID int, req SchedulePriceRequest) (*PriceHistory, error) {
+	schedule, err := s.repo.CreatePriceSchedule(productID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Schedules that are already effective are applied right away
+	if !schedule.EffectiveFrom.After(time.Now()) {
+		if _, err := s.repo.ApplyScheduledPrices(); err != nil {
+			return nil, err
+		}
+	}
+
+	return schedule, nil
+}
+
+func (s *service) GetPriceHistory(productID int) ([]PriceHistory, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPriceHistory(productID)
+}
+
+func (s *service) GetPriceAt(productID int, at time.Time) (*PriceAt, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	h, err := s.repo.GetPriceAt(productID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceAt{ProductID: productID, At: at, Harga: h.Harga, Source: h}, nil
+}
+
+func (s *service) ApplyScheduledPrices() (int64, error) {
+	return s.repo.ApplyScheduledPrices()
+}
+
+// UploadImage stores an image and its thumbnail and makes them the image of
+// the product, deleting the files of the image it replaces.
+func (s *service) UploadImage(id int, data []byte) (*ProductImage, error) {
+	ext, thumbnail, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	imageKey, thumbnailKey, err := newImageKeys(id, ext)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.images.Put(imageKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := s.images.Put(thumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+		s.deleteImages(imageKey)
+		return nil, err
+	}
+
+	replaced, err := s.repo.SetImage(id, &imageKey, &thumbnailKey)
+	if err != nil {
+		s.deleteImages(imageKey, thumbnailKey)
+		return nil, err
+	}
+	s.deleteImages(replaced...)
+
+	return newProductImage(id), nil
+}
+
+// OpenImage returns the image or thumbnail of a product together with its
+// storage key, which changes with every upload.
+func (s *service) OpenImage(id int, thumbnail bool) (io.ReadCloser, string, error) {
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := product.imageKey
+	if thumbnail {
+		key = product.thumbnailKey
+	}
+	if key == nil {
+		return nil, "", apperror.NotFound("product has no image")
+	}
+
+	f, err := s.images.Open(*key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", apperror.NotFound("image not found")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return f, *key, nil
+}
+
+func (s *service) DeleteImage(id int) error {
+	replaced, err := s.repo.SetImage(id, nil, nil)
+	if err != nil {
+		return err
+	}
+	if len(replaced) == 0 {
+		return apperror.NotFound("product has no image")
+	}
+
+	s.deleteImages(replaced...)
+	return nil
+}
+
+// SweepImages removes the images of products archived longer than
+// ArchivedImageRetention, then deletes every stored file no product refers
+// to, such as images left behind by a failed upload or delete. It returns
+// the number of files deleted.
+func (s *service) SweepImages() (int, error) {
+	if _, err := s.repo.ClearArchivedImages(time.Now().Add(-ArchivedImageRetention)); err != nil {
+		return 0, err
+	}
+
+	keys, err := s.repo.GetImageKeys()
+	if err != nil {
+		return 0, err
+	}
+
+	objects, err := s.images.List(imagePrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	cutoff := time.Now().Add(-orphanGrace)
+	for _, obj := range objects {
+		if keys[obj.Key] || obj.ModTime.After(cutoff) {
+			continue
+		}
+		if err := s.images.Delete(obj.Key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
 }
 
-func (s *service) Delete(id int) error {
-	return s.repo.Delete(id)
+// deleteImages removes files that are no longer referenced. Failures are
+// only logged, since the sweep deletes whatever is left behind.
+func (s *service) deleteImages(keys ...string) {
+	for _, key := range keys {
+		if err := s.images.Delete(key); err != nil {
+			log.Printf("Delete image %s: %v", key, err)
+		}
+	}
 }
@@ -0,0 +1,65 @@
+package product
+
+import (
+	"sort"
+	"strings"
+)
+
+// variantKey is a canonical form of a variant's options, used to keep
+// option combinations unique among the variants of one parent. Names and
+// values are compared case-insensitively, so the parts are sorted after
+// lowercasing.
+func variantKey(options map[string]string) string {
+	parts := make([]string, 0, len(options))
+	for name, value := range options {
+		parts = append(parts, strings.ToLower(name)+"="+strings.ToLower(value))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// variantName builds a display name such as "Kaos Polos (L / Hitam)".
+func variantName(parentName string, options map[string]string) string {
+	names := sortedNames(options)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = options[name]
+	}
+	return parentName + " (" + strings.Join(values, " / ") + ")"
+}
+
+// variantMatrix lists every option name used by the variants together with
+// the distinct values in the order they first appear.
+func variantMatrix(variants []ProductDetail) []VariantOption {
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	matrix := make([]VariantOption, 0)
+
+	for _, v := range variants {
+		for _, name := range sortedNames(v.Options) {
+			i, ok := index[name]
+			if !ok {
+				i = len(matrix)
+				index[name] = i
+				matrix = append(matrix, VariantOption{Name: name, Values: []string{}})
+			}
+
+			value := v.Options[name]
+			if !seen[name+"\x00"+value] {
+				seen[name+"\x00"+value] = true
+				matrix[i].Values = append(matrix[i].Values, value)
+			}
+		}
+	}
+
+	return matrix
+}
+
+func sortedNames(options map[string]string) []string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
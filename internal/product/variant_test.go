@@ -0,0 +1,25 @@
+package product
+
+import "testing"
+
+func TestVariantKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    string
+	}{
+		{"no options", map[string]string{}, ""},
+		{"single option", map[string]string{"Ukuran": "L"}, "ukuran=l"},
+		{"sorted by name", map[string]string{"Warna": "Hitam", "Ukuran": "L"}, "ukuran=l;warna=hitam"},
+		{"lowercased", map[string]string{"UKURAN": "XL", "Warna": "Merah Tua"}, "ukuran=xl;warna=merah tua"},
+		{"sorted regardless of case", map[string]string{"ukuran": "L", "Warna": "Hitam"}, "ukuran=l;warna=hitam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := variantKey(tt.options); got != tt.want {
+				t.Errorf("variantKey(%v) = %q, want %q", tt.options, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,30 +1,149 @@
 package transaction
 
-import "time"
+import (
+	"belajar-go/pkg/validation"
+	"fmt"
+	"time"
+)
+
+const (
+	PaymentCash      = "cash"
+	PaymentOnAccount = "on_account"
+	PaymentGiftCard  = "gift_card"
+)
 
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID          int                  `json:"id"`
+	CustomerID  *int                 `json:"customer_id"`
+	TotalAmount int                  `json:"total_amount"`
+	CreatedAt   time.Time            `json:"created_at"`
+	Details     []TransactionDetail  `json:"details"`
+	Payments    []TransactionPayment `json:"payments"`
 }
 
 type TransactionDetail struct {
+	ID            int     `json:"id"`
+	TransactionID int     `json:"transaction_id"`
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name,omitempty"`
+	Quantity      int     `json:"quantity"`
+	Unit          string  `json:"unit"`
+	BaseQuantity  int     `json:"base_quantity"`
+	UnitPrice     int     `json:"unit_price"`
+	PriceListID   *int    `json:"price_list_id"`
+	PriceListName *string `json:"price_list_name"`
+	// PricingRuleID is the time-window rule (e.g. happy hour) applied to
+	// the line, and Discount the total amount it took off the subtotal.
+	PricingRuleID   *int    `json:"pricing_rule_id"`
+	PricingRuleName *string `json:"pricing_rule_name"`
+	Discount        int     `json:"discount"`
+	Subtotal        int     `json:"subtotal"`
+	// Components lists the stock a bundle line consumed and the share of
+	// the subtotal attributed to each component.
+	Components []DetailComponent `json:"components,omitempty"`
+	// Ingredients lists the ingredient stock a recipe product consumed.
+	Ingredients []DetailIngredient `json:"ingredients,omitempty"`
+	// Modifiers are the add-ons chosen for the line. Their price deltas
+	// are already included in UnitPrice.
+	Modifiers []DetailModifier `json:"modifiers,omitempty"`
+}
+
+// DetailModifier is a snapshot of a chosen modifier, so receipts and
+// kitchen tickets still read correctly after the modifier is changed.
+// Quantity is the stock deducted from ProductID for the whole line.
+type DetailModifier struct {
+	ModifierID *int   `json:"modifier_id"`
+	GroupName  string `json:"group_name"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+	ProductID  *int   `json:"product_id,omitempty"`
+	Quantity   int    `json:"quantity,omitempty"`
+}
+
+type DetailIngredient struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+type DetailComponent struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	Revenue     int    `json:"revenue"`
+}
+
+type TransactionPayment struct {
 	ID            int    `json:"id"`
 	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name,omitempty"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	Method        string `json:"method"`
+	Amount        int    `json:"amount"`
+	GiftCardCode  string `json:"gift_card_code,omitempty"`
 }
 
+// CheckoutItem is a line in the checkout. The product is identified by
+// ProductID or, when that is empty, by a scanned Barcode. A product with
+// variants is sold either by the variant's own ID/barcode or by the parent
+// ID plus Options (e.g. {"Ukuran": "L"}). GiftCardCode is required when the
+// product is a gift card; the card is activated with the product price.
+// Unit selects one of the product's units (e.g. "karton"); Quantity is in
+// that unit and defaults to the product's base unit. Modifiers are the IDs
+// of the chosen modifiers and apply to every unit on the line.
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID    int               `json:"product_id"`
+	Barcode      string            `json:"barcode,omitempty"`
+	Options      map[string]string `json:"options,omitempty"`
+	Quantity     int               `json:"quantity"`
+	Unit         string            `json:"unit,omitempty"`
+	GiftCardCode string            `json:"gift_card_code,omitempty"`
+	Modifiers    []int             `json:"modifiers,omitempty"`
+}
+
+// CheckoutPayment is a tender applied to the transaction. An Amount of 0
+// covers whatever is still due; anything left unpaid is settled in cash.
+type CheckoutPayment struct {
+	Method       string `json:"method"`
+	Amount       int    `json:"amount"`
+	GiftCardCode string `json:"gift_card_code,omitempty"`
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	CustomerID *int              `json:"customer_id"`
+	Items      []CheckoutItem    `json:"items"`
+	Payments   []CheckoutPayment `json:"payments"`
+}
+
+func (req CheckoutRequest) Validate() validation.Errors {
+	var v validation.Validator
+	v.Check(len(req.Items) > 0, "items", validation.CodeRequired, "items cannot be empty")
+
+	for i, item := range req.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		v.Check(item.ProductID > 0 || item.Barcode != "", field+".product_id", validation.CodeRequired, "product_id or barcode is required")
+		v.Min(field+".quantity", item.Quantity, 1)
+
+		seen := make(map[int]bool)
+		for j, id := range item.Modifiers {
+			modifierField := fmt.Sprintf("%s.modifiers[%d]", field, j)
+			v.Check(id > 0, modifierField, validation.CodeInvalid, "invalid modifier ID")
+			v.Check(!seen[id], modifierField, validation.CodeDuplicate, "duplicate modifier ID")
+			seen[id] = true
+		}
+	}
+
+	for i, payment := range req.Payments {
+		field := fmt.Sprintf("payments[%d]", i)
+		v.OneOf(field+".method", payment.Method, PaymentCash, PaymentOnAccount, PaymentGiftCard)
+		switch payment.Method {
+		case PaymentOnAccount:
+			v.Check(req.CustomerID != nil, "customer_id", validation.CodeRequired, "customer_id is required for on_account payments")
+		case PaymentGiftCard:
+			v.Required(field+".gift_card_code", payment.GiftCardCode)
+		}
+		v.Min(field+".amount", payment.Amount, 0)
+	}
+
+	return v.Errors()
 }
 
 type DailySalesReport struct {
@@ -37,3 +156,42 @@ type TopProduct struct {
 	Nama       string `json:"nama"`
 	QtyTerjual int    `json:"qty_terjual"`
 }
+
+// ProductSalesReport attributes revenue to products over a date range.
+// Revenue from bundles is split across their components.
+type ProductSalesReport struct {
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Products []ProductSales `json:"products"`
+}
+
+// CategorySalesReport attributes revenue to categories over a date range,
+// sorted by TotalRevenue. Categories without sales are left out.
+type CategorySalesReport struct {
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Categories []CategorySales `json:"categories"`
+}
+
+// CategorySales holds the sales of the products directly in a category,
+// including those sold as part of a bundle, and the totals with all of its
+// subcategories. CategoryID is nil for products without a category.
+type CategorySales struct {
+	CategoryID   *int   `json:"category_id"`
+	Name         string `json:"name"`
+	ParentID     *int   `json:"parent_id"`
+	QtyTerjual   int    `json:"qty_terjual"`
+	Revenue      int    `json:"revenue"`
+	TotalQty     int    `json:"total_qty"`
+	TotalRevenue int    `json:"total_revenue"`
+}
+
+type ProductSales struct {
+	ProductID        int    `json:"product_id"`
+	Nama             string `json:"nama"`
+	QtyTerjual       int    `json:"qty_terjual"`
+	Revenue          int    `json:"revenue"`
+	QtyDariPaket     int    `json:"qty_dari_paket"`
+	RevenueDariPaket int    `json:"revenue_dari_paket"`
+	TotalRevenue     int    `json:"total_revenue"`
+}
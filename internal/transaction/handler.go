@@ -4,6 +4,8 @@ import (
 	"belajar-go/pkg/response"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type Handler struct {
@@ -21,38 +23,92 @@ func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate request
-	if len(req.Items) == 0 {
-		response.Error(w, http.StatusBadRequest, "Items cannot be empty")
+	if errs := req.Validate(); errs != nil {
+		response.ValidationFailed(w, errs)
 		return
 	}
 
-	for _, item := range req.Items {
-		if item.ProductID <= 0 {
-			response.Error(w, http.StatusBadRequest, "Invalid product_id")
-			return
-		}
-		if item.Quantity <= 0 {
-			response.Error(w, http.StatusBadRequest, "Quantity must be greater than 0")
-			return
-		}
+	transaction, err := h.service.Checkout(req)
+	if err != nil {
+		response.FromError(w, err)
+		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
+	response.Success(w, http.StatusCreated, transaction)
+}
+
+// GetByID returns a transaction as printed on receipts and kitchen tickets.
+func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.Error(w, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
-	response.Success(w, http.StatusCreated, transaction)
+	transaction, err := h.service.GetByID(id)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, transaction)
 }
 
 func (h *Handler) GetDailySalesReport(w http.ResponseWriter, r *http.Request) {
 	report, err := h.service.GetDailySalesReport()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, report)
+}
+
+func (h *Handler) GetProductSalesReport(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := reportDates(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetProductSalesReport(from, to)
+	if err != nil {
+		response.FromError(w, err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, report)
 }
+
+func (h *Handler) GetCategorySalesReport(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := reportDates(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetCategorySalesReport(from, to)
+	if err != nil {
+		response.FromError(w, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, report)
+}
+
+// reportDates reads the optional ?from and ?to dates of a report. It writes
+// a 400 and returns false when one is not a YYYY-MM-DD date.
+func reportDates(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+			return "", "", false
+		}
+	}
+
+	return from, to, true
+}
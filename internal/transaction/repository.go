@@ -1,13 +1,26 @@
 package transaction
 
 import (
+	"belajar-go/internal/category"
+	"belajar-go/internal/pricingrule"
+	"belajar-go/internal/product"
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/storetime"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type Repository interface {
-	CreateTransaction(items []CheckoutItem) (*Transaction, error)
+	CreateTransaction(req CheckoutRequest) (*Transaction, error)
+	GetByID(id int) (*Transaction, error)
 	GetDailySalesReport() (*DailySalesReport, error)
+	GetProductSalesReport(from, to string) (*ProductSalesReport, error)
+	GetCategorySalesReport(from, to string) (*CategorySalesReport, error)
 }
 
 type repository struct {
@@ -18,7 +31,7 @@ func NewRepository(db *sql.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) CreateTransaction(items []CheckoutItem) (*Transaction, error) {
+func (r *repository) CreateTransaction(req CheckoutRequest) (*Transaction, error) {
 	// Begin database transaction
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -26,53 +39,181 @@ func (r *repository) CreateTransaction(items []CheckoutItem) (*Transaction, erro
 	}
 	defer tx.Rollback()
 
+	// Validate customer
+	var customerGroup sql.NullString
+	if req.CustomerID != nil {
+		err := tx.QueryRow("SELECT customer_group FROM customers WHERE id = $1", *req.CustomerID).Scan(&customerGroup)
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("customer id %d not found", *req.CustomerID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Time-based pricing rules are evaluated once, at checkout time
+	checkoutTime := storetime.Now()
+
 	totalAmount := 0
 	details := make([]TransactionDetail, 0)
+	activations := make([]giftCardActivation, 0)
 
 	// Process each item
-	for _, item := range items {
+	for _, item := range req.Items {
+		// Resolve scanned barcode
+		if item.ProductID <= 0 {
+			err := tx.QueryRow("SELECT product_id FROM product_barcodes WHERE barcode = $1", item.Barcode).
+				Scan(&item.ProductID)
+			if err == sql.ErrNoRows {
+				return nil, apperror.NotFound("product with barcode %s not found", item.Barcode)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Resolve the variant to sell
+		item.ProductID, err = r.resolveVariant(tx, item.ProductID, item.Options)
+		if err != nil {
+			return nil, err
+		}
+
 		var productPrice, stock int
-		var productName string
+		var productName, productType, baseUnit string
+		var categoryID sql.NullInt64
+		var hasRecipe, archived bool
 
 		// Get product info and check stock
-		err := tx.QueryRow("SELECT nama, harga, stok FROM products WHERE id = $1", item.ProductID).
-			Scan(&productName, &productPrice, &stock)
+		err := tx.QueryRow(`
+			SELECT nama, harga, stok, tipe, base_unit, category_id,
+				EXISTS(SELECT 1 FROM recipe_items ri WHERE ri.product_id = p.id),
+				archived_at IS NOT NULL
+			FROM products p WHERE p.id = $1`, item.ProductID).
+			Scan(&productName, &productPrice, &stock, &productType, &baseUnit, &categoryID, &hasRecipe, &archived)
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+			return nil, apperror.NotFound("product id %d not found", item.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if archived {
+			return nil, apperror.Conflict("product %s is archived and cannot be sold", productName)
+		}
+
+		// Convert the selling unit to base units
+		unit, err := r.resolveUnit(tx, item.ProductID, item.Unit, baseUnit)
+		if err != nil {
+			return nil, err
 		}
+		baseQuantity := item.Quantity * unit.conversion
+
+		// Validate stock (bundles and recipes are checked per component when
+		// consumed; gift cards are not stocked)
+		if productType != product.TypeBundle && productType != product.TypeGiftCard && !hasRecipe && stock < baseQuantity {
+			return nil, apperror.InsufficientStock("insufficient stock for product %s (available: %d %s, requested: %d %s)",
+				productName, stock, baseUnit, baseQuantity, baseUnit)
+		}
+
+		// Resolve unit price from price lists, then scale it to the selling unit
+		price, err := r.resolvePrice(tx, item.ProductID, baseQuantity, customerGroup, productPrice)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice := price.unitPrice * unit.conversion
+		if unit.harga != nil {
+			unitPrice = *unit.harga
+			price.priceListID = nil
+			price.priceListName = nil
+		}
+
+		// Apply time-window pricing rules (happy hour)
+		var rule *appliedRule
+		if productType != product.TypeGiftCard {
+			rule, err = r.findPricingRule(tx, item.ProductID, categoryID, checkoutTime)
+			if err != nil {
+				return nil, err
+			}
+		}
+		discount := 0
+		if rule != nil {
+			unitDiscount := unitPrice * rule.discountPercent / 100
+			unitPrice -= unitDiscount
+			discount = unitDiscount * item.Quantity
+		}
+
+		// Add the chosen modifiers (extra cheese, less sugar, ...)
+		modifiers, priceDelta, err := r.resolveModifiers(tx, item.ProductID, productName, item.Modifiers)
 		if err != nil {
 			return nil, err
 		}
+		unitPrice += priceDelta
 
-		// Validate stock
-		if stock < item.Quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)",
-				productName, stock, item.Quantity)
+		// Gift cards are sold one per code and activated with the price
+		// charged for them
+		if productType == product.TypeGiftCard {
+			if item.GiftCardCode == "" {
+				return nil, apperror.Validation("gift_card_code is required for product %s", productName)
+			}
+			if item.Quantity != 1 {
+				return nil, apperror.Validation("gift card %s must be sold with quantity 1", productName)
+			}
+			activations = append(activations, giftCardActivation{
+				code:      item.GiftCardCode,
+				productID: item.ProductID,
+				amount:    unitPrice,
+			})
 		}
 
 		// Calculate subtotal
-		subtotal := productPrice * item.Quantity
+		subtotal := unitPrice * item.Quantity
 		totalAmount += subtotal
 
 		// Update product stock
-		_, err = tx.Exec("UPDATE products SET stok = stok - $1 WHERE id = $2", item.Quantity, item.ProductID)
+		var components []DetailComponent
+		var ingredients []DetailIngredient
+		switch {
+		case productType == product.TypeGiftCard:
+			// Gift cards have no stock to deduct
+		case productType == product.TypeBundle:
+			components, err = r.consumeBundle(tx, item.ProductID, productName, baseQuantity, subtotal)
+		case hasRecipe:
+			ingredients, err = r.consumeRecipe(tx, item.ProductID, productName, baseQuantity)
+		default:
+			_, err = tx.Exec("UPDATE products SET stok = stok - $1 WHERE id = $2", baseQuantity, item.ProductID)
+		}
 		if err != nil {
 			return nil, err
 		}
+		if err := r.consumeModifiers(tx, modifiers, item.Quantity); err != nil {
+			return nil, err
+		}
 
 		// Prepare detail
 		details = append(details, TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
+			ProductID:     item.ProductID,
+			ProductName:   productName,
+			Quantity:      item.Quantity,
+			Unit:          unit.name,
+			BaseQuantity:  baseQuantity,
+			UnitPrice:     unitPrice,
+			PriceListID:   price.priceListID,
+			PriceListName: price.priceListName,
+			Discount:      discount,
+			Subtotal:      subtotal,
+			Components:    components,
+			Ingredients:   ingredients,
+			Modifiers:     modifiers,
 		})
+		if rule != nil {
+			details[len(details)-1].PricingRuleID = &rule.id
+			details[len(details)-1].PricingRuleName = &rule.name
+		}
 	}
 
 	// Insert transaction
 	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).
-		Scan(&transactionID)
+	err = tx.QueryRow("INSERT INTO transactions (customer_id, total_amount) VALUES ($1, $2) RETURNING id",
+		req.CustomerID, totalAmount).Scan(&transactionID)
 	if err != nil {
 		return nil, err
 	}
@@ -82,13 +223,65 @@ func (r *repository) CreateTransaction(items []CheckoutItem) (*Transaction, erro
 		details[i].TransactionID = transactionID
 		var detailID int
 		err = tx.QueryRow(
-			"INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4) RETURNING id",
-			transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal,
+			`INSERT INTO transaction_details
+				(transaction_id, product_id, quantity, unit, base_quantity, unit_price, price_list_id,
+				pricing_rule_id, discount, subtotal)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+			transactionID, details[i].ProductID, details[i].Quantity, details[i].Unit, details[i].BaseQuantity,
+			details[i].UnitPrice, details[i].PriceListID, details[i].PricingRuleID, details[i].Discount, details[i].Subtotal,
 		).Scan(&detailID)
 		if err != nil {
 			return nil, err
 		}
 		details[i].ID = detailID
+
+		for _, c := range details[i].Components {
+			_, err = tx.Exec(
+				`INSERT INTO transaction_detail_components (transaction_detail_id, product_id, quantity, revenue)
+				VALUES ($1, $2, $3, $4)`,
+				detailID, c.ProductID, c.Quantity, c.Revenue,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, ing := range details[i].Ingredients {
+			_, err = tx.Exec(
+				`INSERT INTO transaction_detail_ingredients (transaction_detail_id, product_id, quantity)
+				VALUES ($1, $2, $3)`,
+				detailID, ing.ProductID, ing.Quantity,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, m := range details[i].Modifiers {
+			_, err = tx.Exec(
+				`INSERT INTO transaction_detail_modifiers
+					(transaction_detail_id, modifier_id, group_name, name, price_delta, product_id, quantity)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				detailID, m.ModifierID, m.GroupName, m.Name, m.PriceDelta, m.ProductID, m.Quantity,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Settle payments before activating the gift cards sold here, so a card
+	// cannot pay for itself
+	payments, err := r.applyPayments(tx, transactionID, req.CustomerID, totalAmount, req.Payments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Activate gift cards sold in this transaction
+	for _, a := range activations {
+		if err := r.activateGiftCard(tx, transactionID, a); err != nil {
+			return nil, err
+		}
 	}
 
 	// Commit transaction
@@ -105,12 +298,245 @@ func (r *repository) CreateTransaction(items []CheckoutItem) (*Transaction, erro
 
 	return &Transaction{
 		ID:          transactionID,
+		CustomerID:  req.CustomerID,
 		TotalAmount: totalAmount,
 		CreatedAt:   createdAt.Time,
 		Details:     details,
+		Payments:    payments,
 	}, nil
 }
 
+// GetByID loads a transaction with everything printed on the receipt and
+// kitchen ticket: lines with their modifiers, components and ingredients,
+// and the payments.
+func (r *repository) GetByID(id int) (*Transaction, error) {
+	t := Transaction{ID: id}
+	err := r.db.QueryRow("SELECT customer_id, total_amount, created_at FROM transactions WHERE id = $1", id).
+		Scan(&t.CustomerID, &t.TotalAmount, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("transaction not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT td.id, td.product_id, p.nama, td.quantity, td.unit, td.base_quantity, td.unit_price,
+			td.price_list_id, pl.name, td.pricing_rule_id, pr.name, td.discount, td.subtotal
+		FROM transaction_details td
+		JOIN products p ON td.product_id = p.id
+		LEFT JOIN price_lists pl ON td.price_list_id = pl.id
+		LEFT JOIN pricing_rules pr ON td.pricing_rule_id = pr.id
+		WHERE td.transaction_id = $1
+		ORDER BY td.id ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	t.Details = make([]TransactionDetail, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		d := TransactionDetail{TransactionID: id}
+		if err := rows.Scan(&d.ID, &d.ProductID, &d.ProductName, &d.Quantity, &d.Unit, &d.BaseQuantity, &d.UnitPrice,
+			&d.PriceListID, &d.PriceListName, &d.PricingRuleID, &d.PricingRuleName, &d.Discount, &d.Subtotal); err != nil {
+			return nil, err
+		}
+		index[d.ID] = len(t.Details)
+		t.Details = append(t.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	modifierRows, err := r.db.Query(`
+		SELECT m.transaction_detail_id, m.modifier_id, m.group_name, m.name, m.price_delta, m.product_id, m.quantity
+		FROM transaction_detail_modifiers m
+		JOIN transaction_details td ON m.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1
+		ORDER BY m.id ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer modifierRows.Close()
+
+	for modifierRows.Next() {
+		var detailID int
+		var m DetailModifier
+		if err := modifierRows.Scan(&detailID, &m.ModifierID, &m.GroupName, &m.Name, &m.PriceDelta, &m.ProductID, &m.Quantity); err != nil {
+			return nil, err
+		}
+		d := &t.Details[index[detailID]]
+		d.Modifiers = append(d.Modifiers, m)
+	}
+	if err := modifierRows.Err(); err != nil {
+		return nil, err
+	}
+
+	componentRows, err := r.db.Query(`
+		SELECT c.transaction_detail_id, c.product_id, p.nama, c.quantity, c.revenue
+		FROM transaction_detail_components c
+		JOIN transaction_details td ON c.transaction_detail_id = td.id
+		JOIN products p ON c.product_id = p.id
+		WHERE td.transaction_id = $1
+		ORDER BY c.id ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer componentRows.Close()
+
+	for componentRows.Next() {
+		var detailID int
+		var c DetailComponent
+		if err := componentRows.Scan(&detailID, &c.ProductID, &c.ProductName, &c.Quantity, &c.Revenue); err != nil {
+			return nil, err
+		}
+		d := &t.Details[index[detailID]]
+		d.Components = append(d.Components, c)
+	}
+	if err := componentRows.Err(); err != nil {
+		return nil, err
+	}
+
+	ingredientRows, err := r.db.Query(`
+		SELECT i.transaction_detail_id, i.product_id, p.nama, i.quantity
+		FROM transaction_detail_ingredients i
+		JOIN transaction_details td ON i.transaction_detail_id = td.id
+		JOIN products p ON i.product_id = p.id
+		WHERE td.transaction_id = $1
+		ORDER BY i.id ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer ingredientRows.Close()
+
+	for ingredientRows.Next() {
+		var detailID int
+		var ing DetailIngredient
+		if err := ingredientRows.Scan(&detailID, &ing.ProductID, &ing.ProductName, &ing.Quantity); err != nil {
+			return nil, err
+		}
+		d := &t.Details[index[detailID]]
+		d.Ingredients = append(d.Ingredients, ing)
+	}
+	if err := ingredientRows.Err(); err != nil {
+		return nil, err
+	}
+
+	paymentRows, err := r.db.Query(`
+		SELECT id, method, amount, COALESCE(gift_card_code, '')
+		FROM transaction_payments
+		WHERE transaction_id = $1
+		ORDER BY id ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer paymentRows.Close()
+
+	t.Payments = make([]TransactionPayment, 0)
+	for paymentRows.Next() {
+		p := TransactionPayment{TransactionID: id}
+		if err := paymentRows.Scan(&p.ID, &p.Method, &p.Amount, &p.GiftCardCode); err != nil {
+			return nil, err
+		}
+		t.Payments = append(t.Payments, p)
+	}
+	if err := paymentRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// applyPayments records each tender against the transaction. On-account
+// tenders are booked to the customer's ledger after checking the credit
+// limit; any amount not covered by a tender is recorded as cash.
+func (r *repository) applyPayments(tx *sql.Tx, transactionID int, customerID *int, totalAmount int, requested []CheckoutPayment) ([]TransactionPayment, error) {
+	remaining := totalAmount
+	payments := make([]TransactionPayment, 0, len(requested)+1)
+
+	for _, p := range requested {
+		amount := p.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return nil, apperror.Validation("payments exceed total amount %d", totalAmount)
+		}
+		if amount == 0 {
+			continue
+		}
+
+		switch p.Method {
+		case PaymentOnAccount:
+			if err := r.chargeCustomer(tx, *customerID, transactionID, amount); err != nil {
+				return nil, err
+			}
+		case PaymentGiftCard:
+			redeemed, err := r.redeemGiftCard(tx, transactionID, p.GiftCardCode, amount, p.Amount == 0)
+			if err != nil {
+				return nil, err
+			}
+			amount = redeemed
+		}
+
+		payments = append(payments, TransactionPayment{Method: p.Method, Amount: amount, GiftCardCode: p.GiftCardCode})
+		remaining -= amount
+	}
+
+	if remaining > 0 {
+		payments = append(payments, TransactionPayment{Method: PaymentCash, Amount: remaining})
+	}
+
+	for i := range payments {
+		payments[i].TransactionID = transactionID
+		err := tx.QueryRow(
+			"INSERT INTO transaction_payments (transaction_id, method, amount, gift_card_code) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id",
+			transactionID, payments[i].Method, payments[i].Amount, payments[i].GiftCardCode,
+		).Scan(&payments[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return payments, nil
+}
+
+func (r *repository) chargeCustomer(tx *sql.Tx, customerID, transactionID, amount int) error {
+	// Lock the customer so concurrent checkouts cannot exceed the credit limit together
+	var name string
+	var creditLimit int
+	err := tx.QueryRow("SELECT name, credit_limit FROM customers WHERE id = $1 FOR UPDATE", customerID).
+		Scan(&name, &creditLimit)
+	if err != nil {
+		return err
+	}
+
+	var balance int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN entry_type = 'charge' THEN amount ELSE -amount END), 0)
+		FROM customer_ledger WHERE customer_id = $1`, customerID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+
+	if balance+amount > creditLimit {
+		return apperror.Conflict("credit limit exceeded for customer %s (limit: %d, balance: %d, requested: %d)",
+			name, creditLimit, balance, amount)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO customer_ledger (customer_id, entry_type, amount, transaction_id) VALUES ($1, 'charge', $2, $3)",
+		customerID, amount, transactionID,
+	)
+	return err
+}
+
 func (r *repository) GetDailySalesReport() (*DailySalesReport, error) {
 	report := &DailySalesReport{}
 
@@ -133,7 +559,7 @@ func (r *repository) GetDailySalesReport() (*DailySalesReport, error) {
 	err = r.db.QueryRow(`
 		SELECT
 			p.nama,
-			COALESCE(SUM(td.quantity), 0) as qty
+			COALESCE(SUM(td.base_quantity), 0) as qty
 		FROM transaction_details td
 		JOIN transactions t ON td.transaction_id = t.id
 		JOIN products p ON td.product_id = p.id
@@ -157,3 +583,693 @@ func (r *repository) GetDailySalesReport() (*DailySalesReport, error) {
 
 	return report, nil
 }
+
+// resolveVariant returns the product to sell for a line. Products with
+// variants cannot be sold directly; the variant is picked by its options.
+func (r *repository) resolveVariant(tx *sql.Tx, productID int, options map[string]string) (int, error) {
+	var hasVariants bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE parent_id = $1 AND archived_at IS NULL)", productID).Scan(&hasVariants)
+	if err != nil {
+		return 0, err
+	}
+
+	if !hasVariants {
+		if len(options) > 0 {
+			return 0, apperror.Validation("product id %d has no variants", productID)
+		}
+		return productID, nil
+	}
+	if len(options) == 0 {
+		return 0, apperror.Validation("product id %d has variants, select a variant", productID)
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return 0, err
+	}
+
+	var variantID int
+	err = tx.QueryRow(`
+		SELECT v.id
+		FROM products v
+		WHERE v.parent_id = $1
+			AND v.archived_at IS NULL
+			AND (SELECT COUNT(*) FROM product_variant_attributes a WHERE a.product_id = v.id) = $3
+			AND NOT EXISTS (
+				SELECT 1 FROM jsonb_each_text($2::jsonb) o
+				WHERE NOT EXISTS (
+					SELECT 1 FROM product_variant_attributes a
+					WHERE a.product_id = v.id
+						AND LOWER(a.name) = LOWER(o.key)
+						AND LOWER(a.value) = LOWER(o.value)
+				)
+			)
+	`, productID, string(optionsJSON), len(options)).Scan(&variantID)
+	if err == sql.ErrNoRows {
+		return 0, apperror.Validation("no variant of product id %d matches the selected options", productID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return variantID, nil
+}
+
+// consumeBundle deducts the component stock for quantity bundles and splits
+// revenue across the components in proportion to their list value.
+func (r *repository) consumeBundle(tx *sql.Tx, bundleID int, bundleName string, quantity, revenue int) ([]DetailComponent, error) {
+	rows, err := tx.Query(`
+		SELECT bc.component_id, p.nama, p.harga, p.stok, bc.quantity
+		FROM bundle_components bc
+		JOIN products p ON bc.component_id = p.id
+		WHERE bc.bundle_id = $1
+		ORDER BY bc.component_id ASC
+		FOR UPDATE OF p
+	`, bundleID)
+	if err != nil {
+		return nil, err
+	}
+
+	type component struct {
+		DetailComponent
+		harga, stok int
+	}
+	var parts []component
+	var values []int
+	for rows.Next() {
+		var c component
+		var perBundle int
+		if err := rows.Scan(&c.ProductID, &c.ProductName, &c.harga, &c.stok, &perBundle); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.Quantity = perBundle * quantity
+		parts = append(parts, c)
+		values = append(values, c.harga*c.Quantity)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 0 {
+		return nil, apperror.Validation("bundle %s has no components", bundleName)
+	}
+
+	shares := splitRevenue(revenue, values)
+	components := make([]DetailComponent, len(parts))
+	for i, c := range parts {
+		if c.stok < c.Quantity {
+			return nil, apperror.InsufficientStock("insufficient stock for product %s in bundle %s (available: %d, requested: %d)",
+				c.ProductName, bundleName, c.stok, c.Quantity)
+		}
+		c.Revenue = shares[i]
+
+		_, err := tx.Exec("UPDATE products SET stok = stok - $1 WHERE id = $2", c.Quantity, c.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		components[i] = c.DetailComponent
+	}
+
+	return components, nil
+}
+
+// splitRevenue divides the revenue of a bundle line over its components in
+// proportion to values, their harga times quantity, or evenly when every
+// value is 0. The last component takes the rounding remainder, so the
+// shares add up to revenue.
+func splitRevenue(revenue int, values []int) []int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+
+	shares := make([]int, len(values))
+	allocated := 0
+	for i, v := range values {
+		switch {
+		case i == len(values)-1:
+			shares[i] = revenue - allocated
+		case total > 0:
+			shares[i] = revenue * v / total
+		default:
+			shares[i] = revenue / len(values)
+		}
+		allocated += shares[i]
+	}
+	return shares
+}
+
+// consumeRecipe deducts the ingredient stock used to make quantity units of
+// a recipe product.
+func (r *repository) consumeRecipe(tx *sql.Tx, productID int, productName string, quantity int) ([]DetailIngredient, error) {
+	rows, err := tx.Query(`
+		SELECT ri.ingredient_id, p.nama, p.stok, p.base_unit, ri.quantity
+		FROM recipe_items ri
+		JOIN products p ON ri.ingredient_id = p.id
+		WHERE ri.product_id = $1
+		ORDER BY ri.ingredient_id ASC
+		FOR UPDATE OF p
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	type ingredient struct {
+		DetailIngredient
+		stok     int
+		baseUnit string
+	}
+	var parts []ingredient
+	for rows.Next() {
+		var ing ingredient
+		var perUnit int
+		if err := rows.Scan(&ing.ProductID, &ing.ProductName, &ing.stok, &ing.baseUnit, &perUnit); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ing.Quantity = perUnit * quantity
+		parts = append(parts, ing)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ingredients := make([]DetailIngredient, len(parts))
+	for i, ing := range parts {
+		if ing.stok < ing.Quantity {
+			return nil, apperror.InsufficientStock("insufficient stock for ingredient %s in %s (available: %d %s, requested: %d %s)",
+				ing.ProductName, productName, ing.stok, ing.baseUnit, ing.Quantity, ing.baseUnit)
+		}
+
+		_, err := tx.Exec("UPDATE products SET stok = stok - $1 WHERE id = $2", ing.Quantity, ing.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		ingredients[i] = ing.DetailIngredient
+	}
+
+	return ingredients, nil
+}
+
+// resolveModifiers validates the chosen modifiers against the groups
+// attached to the product (or to its parent, for a variant) and returns them
+// with the total price delta per unit.
+func (r *repository) resolveModifiers(tx *sql.Tx, productID int, productName string, chosen []int) ([]DetailModifier, int, error) {
+	rows, err := tx.Query(`
+		SELECT DISTINCT g.id, g.name, g.min_select, g.max_select
+		FROM product_modifier_groups pmg
+		JOIN modifier_groups g ON pmg.group_id = g.id
+		WHERE pmg.product_id = $1
+			OR pmg.product_id = (SELECT parent_id FROM products WHERE id = $1)
+		ORDER BY g.id ASC
+	`, productID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var groupList []*modifierGroup
+	groups := make(map[int]*modifierGroup)
+	for rows.Next() {
+		var id int
+		g := &modifierGroup{}
+		if err := rows.Scan(&id, &g.name, &g.minSelect, &g.maxSelect); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		groups[id] = g
+		groupList = append(groupList, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	modifiers := make([]DetailModifier, 0, len(chosen))
+	priceDelta := 0
+	for _, id := range chosen {
+		var groupID, perUnit int
+		var productID sql.NullInt64
+		m := DetailModifier{ModifierID: &id}
+		err := tx.QueryRow("SELECT group_id, name, price_delta, product_id, quantity FROM modifiers WHERE id = $1", id).
+			Scan(&groupID, &m.Name, &m.PriceDelta, &productID, &perUnit)
+		if err == sql.ErrNoRows {
+			return nil, 0, apperror.NotFound("modifier id %d not found", id)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		g, ok := groups[groupID]
+		if !ok {
+			return nil, 0, apperror.Validation("modifier %s is not available for product %s", m.Name, productName)
+		}
+		g.selected++
+		m.GroupName = g.name
+		if productID.Valid {
+			pid := int(productID.Int64)
+			m.ProductID = &pid
+			m.Quantity = perUnit
+		}
+
+		priceDelta += m.PriceDelta
+		modifiers = append(modifiers, m)
+	}
+
+	if err := checkModifierSelection(groupList, productName); err != nil {
+		return nil, 0, err
+	}
+
+	return modifiers, priceDelta, nil
+}
+
+// modifierGroup is a modifier group offered for a product, with the number
+// of its modifiers chosen on a line.
+type modifierGroup struct {
+	name                 string
+	minSelect, maxSelect int
+	selected             int
+}
+
+// checkModifierSelection makes sure each group got at least minSelect and,
+// unless maxSelect is 0, at most maxSelect of its modifiers. The first
+// group that did not is reported.
+func checkModifierSelection(groups []*modifierGroup, productName string) error {
+	for _, g := range groups {
+		if g.selected < g.minSelect {
+			return apperror.Validation("choose at least %d from %s for product %s", g.minSelect, g.name, productName)
+		}
+		if g.maxSelect > 0 && g.selected > g.maxSelect {
+			return apperror.Validation("choose at most %d from %s for product %s", g.maxSelect, g.name, productName)
+		}
+	}
+	return nil
+}
+
+// consumeModifiers deducts the stock of modifiers linked to a product (e.g.
+// "tambah telur") for quantity units sold. The quantities on modifiers are
+// scaled to the whole line.
+func (r *repository) consumeModifiers(tx *sql.Tx, modifiers []DetailModifier, quantity int) error {
+	for i := range modifiers {
+		m := &modifiers[i]
+		if m.ProductID == nil {
+			continue
+		}
+		m.Quantity *= quantity
+
+		var name string
+		var stock int
+		err := tx.QueryRow("SELECT nama, stok FROM products WHERE id = $1 FOR UPDATE", *m.ProductID).Scan(&name, &stock)
+		if err != nil {
+			return err
+		}
+		if stock < m.Quantity {
+			return apperror.InsufficientStock("insufficient stock for product %s used by modifier %s (available: %d, requested: %d)",
+				name, m.Name, stock, m.Quantity)
+		}
+
+		if _, err := tx.Exec("UPDATE products SET stok = stok - $1 WHERE id = $2", m.Quantity, *m.ProductID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type sellingUnit struct {
+	name       string
+	conversion int
+	harga      *int
+}
+
+// resolveUnit looks up the unit a line is sold in. An empty name or the
+// product's base unit converts 1:1.
+func (r *repository) resolveUnit(tx *sql.Tx, productID int, name, baseUnit string) (sellingUnit, error) {
+	if name == "" || name == baseUnit {
+		return sellingUnit{name: baseUnit, conversion: 1}, nil
+	}
+
+	unit := sellingUnit{name: name}
+	err := tx.QueryRow("SELECT conversion, harga FROM product_units WHERE product_id = $1 AND name = $2", productID, name).
+		Scan(&unit.conversion, &unit.harga)
+	if err == sql.ErrNoRows {
+		return sellingUnit{}, apperror.Validation("unit %s is not available for product id %d", name, productID)
+	}
+	if err != nil {
+		return sellingUnit{}, err
+	}
+
+	return unit, nil
+}
+
+type resolvedPrice struct {
+	unitPrice     int
+	priceListID   *int
+	priceListName *string
+}
+
+// priceListEntry is a quantity break of a price list for one product.
+type priceListEntry struct {
+	listID        int
+	listName      string
+	customerGroup sql.NullString
+	minQty        int
+	harga         int
+}
+
+// resolvePrice picks the unit price for a line from the price list entries
+// of its product. Without a matching entry the product's own harga is used.
+func (r *repository) resolvePrice(tx *sql.Tx, productID, quantity int, customerGroup sql.NullString, basePrice int) (resolvedPrice, error) {
+	rows, err := tx.Query(`
+		SELECT pl.id, pl.name, pl.customer_group, i.min_qty, i.harga
+		FROM price_list_items i
+		JOIN price_lists pl ON i.price_list_id = pl.id
+		WHERE i.product_id = $1
+	`, productID)
+	if err != nil {
+		return resolvedPrice{}, err
+	}
+	defer rows.Close()
+
+	var entries []priceListEntry
+	for rows.Next() {
+		var e priceListEntry
+		if err := rows.Scan(&e.listID, &e.listName, &e.customerGroup, &e.minQty, &e.harga); err != nil {
+			return resolvedPrice{}, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return resolvedPrice{}, err
+	}
+
+	e := pickPriceListEntry(entries, quantity, customerGroup)
+	if e == nil {
+		return resolvedPrice{unitPrice: basePrice}, nil
+	}
+
+	return resolvedPrice{
+		unitPrice:     e.harga,
+		priceListID:   &e.listID,
+		priceListName: &e.listName,
+	}, nil
+}
+
+// pickPriceListEntry returns the entry that prices quantity units for a
+// customer of customerGroup, or nil. A price list for the customer's group
+// wins over a group-less list; within those the highest quantity break
+// reached applies, and the lowest list ID breaks ties.
+func pickPriceListEntry(entries []priceListEntry, quantity int, customerGroup sql.NullString) *priceListEntry {
+	var best *priceListEntry
+	for i := range entries {
+		e := &entries[i]
+		if e.minQty > quantity {
+			continue
+		}
+		if e.customerGroup.Valid && (!customerGroup.Valid || e.customerGroup.String != customerGroup.String) {
+			continue
+		}
+
+		if best == nil {
+			best = e
+			continue
+		}
+		switch {
+		case e.customerGroup.Valid != best.customerGroup.Valid:
+			if e.customerGroup.Valid {
+				best = e
+			}
+		case e.minQty != best.minQty:
+			if e.minQty > best.minQty {
+				best = e
+			}
+		case e.listID < best.listID:
+			best = e
+		}
+	}
+	return best
+}
+
+type appliedRule struct {
+	id              int
+	name            string
+	discountPercent int
+}
+
+// findPricingRule returns the active rule with the largest discount whose
+// time window contains at, scoped to the product or to its category or one
+// of the category's parents.
+func (r *repository) findPricingRule(tx *sql.Tx, productID int, categoryID sql.NullInt64, at time.Time) (*appliedRule, error) {
+	rows, err := tx.Query(`
+		SELECT id, name, discount_percent, days, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM pricing_rules
+		WHERE active
+			AND (product_id = $1 OR category_id IN (`+fmt.Sprintf(category.AncestorIDs, 2)+`))
+		ORDER BY discount_percent DESC, id ASC
+	`, productID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule pricingrule.PricingRule
+		err := rows.Scan(&rule.ID, &rule.Name, &rule.DiscountPercent, pq.Array(&rule.Days), &rule.StartTime, &rule.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		if rule.InWindow(at) {
+			return &appliedRule{id: rule.ID, name: rule.Name, discountPercent: rule.DiscountPercent}, nil
+		}
+	}
+
+	return nil, rows.Err()
+}
+
+type giftCardActivation struct {
+	code      string
+	productID int
+	amount    int
+}
+
+// activateGiftCard creates the card and its opening balance. ON CONFLICT
+// lets two checkouts racing for the same code end in a conflict instead of
+// a unique violation.
+func (r *repository) activateGiftCard(tx *sql.Tx, transactionID int, a giftCardActivation) error {
+	var giftCardID int
+	err := tx.QueryRow(
+		`INSERT INTO gift_cards (code, product_id, initial_balance) VALUES ($1, $2, $3)
+		ON CONFLICT (code) DO NOTHING RETURNING id`,
+		a.code, a.productID, a.amount,
+	).Scan(&giftCardID)
+	if err == sql.ErrNoRows {
+		return apperror.Conflict("gift card %s is already activated", a.code)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO gift_card_movements (gift_card_id, movement_type, amount, transaction_id) VALUES ($1, 'activation', $2, $3)",
+		giftCardID, a.amount, transactionID,
+	)
+	return err
+}
+
+// redeemGiftCard deducts amount from the card balance and returns the amount
+// actually redeemed. When partial is true the amount is capped at the
+// available balance instead of failing.
+func (r *repository) redeemGiftCard(tx *sql.Tx, transactionID int, code string, amount int, partial bool) (int, error) {
+	// Lock the card so concurrent redemptions are serialized
+	var giftCardID int
+	err := tx.QueryRow("SELECT id FROM gift_cards WHERE code = $1 FOR UPDATE", code).Scan(&giftCardID)
+	if err == sql.ErrNoRows {
+		return 0, apperror.NotFound("gift card %s not found", code)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var balance int
+	err = tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM gift_card_movements WHERE gift_card_id = $1", giftCardID).
+		Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+
+	if partial && amount > balance {
+		amount = balance
+	}
+	if amount == 0 || amount > balance {
+		return 0, apperror.InsufficientStock("insufficient gift card balance for %s (available: %d, requested: %d)", code, balance, amount)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO gift_card_movements (gift_card_id, movement_type, amount, transaction_id) VALUES ($1, 'redemption', $2, $3)",
+		giftCardID, -amount, transactionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+// salesCTE defines sales, one row per product sold between $1 and $2.
+// Bundles are replaced by their components, which carry the split revenue
+// in bundle_qty and bundle_revenue.
+const salesCTE = `
+		WITH sales AS (
+			SELECT td.product_id, td.base_quantity AS qty, td.subtotal AS revenue, 0 AS bundle_qty, 0 AS bundle_revenue
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE DATE(t.created_at) BETWEEN $1 AND $2
+				AND NOT EXISTS (SELECT 1 FROM transaction_detail_components c WHERE c.transaction_detail_id = td.id)
+			UNION ALL
+			SELECT c.product_id, 0, 0, c.quantity, c.revenue
+			FROM transaction_detail_components c
+			JOIN transaction_details td ON c.transaction_detail_id = td.id
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE DATE(t.created_at) BETWEEN $1 AND $2
+		)`
+
+// reportRange resolves the from and to dates of a report (YYYY-MM-DD);
+// empty dates default to today.
+func (r *repository) reportRange(from, to string) (string, string, error) {
+	var fromArg, toArg interface{}
+	if from != "" {
+		fromArg = from
+	}
+	if to != "" {
+		toArg = to
+	}
+
+	err := r.db.QueryRow("SELECT COALESCE($1::date, CURRENT_DATE)::text, COALESCE($2::date, CURRENT_DATE)::text", fromArg, toArg).
+		Scan(&from, &to)
+	return from, to, err
+}
+
+// GetProductSalesReport reports sales per product between from and to
+// (inclusive, YYYY-MM-DD); empty dates default to today.
+func (r *repository) GetProductSalesReport(from, to string) (*ProductSalesReport, error) {
+	report := &ProductSalesReport{Products: make([]ProductSales, 0)}
+	var err error
+	report.From, report.To, err = r.reportRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(salesCTE+`
+		SELECT
+			p.id,
+			p.nama,
+			SUM(s.qty),
+			SUM(s.revenue),
+			SUM(s.bundle_qty),
+			SUM(s.bundle_revenue),
+			SUM(s.revenue + s.bundle_revenue) AS total
+		FROM sales s
+		JOIN products p ON s.product_id = p.id
+		GROUP BY p.id, p.nama
+		ORDER BY total DESC, p.id ASC
+	`, report.From, report.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ps ProductSales
+		if err := rows.Scan(&ps.ProductID, &ps.Nama, &ps.QtyTerjual, &ps.Revenue,
+			&ps.QtyDariPaket, &ps.RevenueDariPaket, &ps.TotalRevenue); err != nil {
+			return nil, err
+		}
+		report.Products = append(report.Products, ps)
+	}
+
+	return report, nil
+}
+
+// GetCategorySalesReport reports sales per category between from and to
+// (inclusive, YYYY-MM-DD). Each category also totals its subcategories, so
+// a parent sums up everything below it. Products without a category are
+// reported as "Tanpa Kategori".
+func (r *repository) GetCategorySalesReport(from, to string) (*CategorySalesReport, error) {
+	report := &CategorySalesReport{Categories: make([]CategorySales, 0)}
+	var err error
+	report.From, report.To, err = r.reportRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Archived categories are included, they may still have sales
+	rows, err := r.db.Query(salesCTE+`,
+		own AS (
+			SELECT p.category_id, SUM(s.qty + s.bundle_qty) AS qty, SUM(s.revenue + s.bundle_revenue) AS revenue
+			FROM sales s
+			JOIN products p ON s.product_id = p.id
+			GROUP BY p.category_id
+		)
+		SELECT c.id, c.name, c.parent_id, COALESCE(o.qty, 0), COALESCE(o.revenue, 0)
+		FROM categories c
+		LEFT JOIN own o ON o.category_id = c.id
+		UNION ALL
+		SELECT NULL, 'Tanpa Kategori', NULL, qty, revenue
+		FROM own
+		WHERE category_id IS NULL
+		ORDER BY 1 NULLS LAST
+	`, report.From, report.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []CategorySales
+	index := make(map[int]int)
+	for rows.Next() {
+		var cs CategorySales
+		if err := rows.Scan(&cs.CategoryID, &cs.Name, &cs.ParentID, &cs.QtyTerjual, &cs.Revenue); err != nil {
+			return nil, err
+		}
+		cs.TotalQty, cs.TotalRevenue = cs.QtyTerjual, cs.Revenue
+		if cs.CategoryID != nil {
+			index[*cs.CategoryID] = len(all)
+		}
+		all = append(all, cs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Add the sales of each category to all of its ancestors
+	for _, cs := range all {
+		if cs.QtyTerjual == 0 && cs.Revenue == 0 {
+			continue
+		}
+		seen := make(map[int]bool)
+		for parentID := cs.ParentID; parentID != nil && !seen[*parentID]; {
+			seen[*parentID] = true
+			i, ok := index[*parentID]
+			if !ok {
+				break
+			}
+			all[i].TotalQty += cs.QtyTerjual
+			all[i].TotalRevenue += cs.Revenue
+			parentID = all[i].ParentID
+		}
+	}
+
+	for _, cs := range all {
+		if cs.TotalQty != 0 || cs.TotalRevenue != 0 {
+			report.Categories = append(report.Categories, cs)
+		}
+	}
+	sort.SliceStable(report.Categories, func(i, j int) bool {
+		return report.Categories[i].TotalRevenue > report.Categories[j].TotalRevenue
+	})
+
+	return report, nil
+}
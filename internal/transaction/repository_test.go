@@ -0,0 +1,123 @@
+package transaction
+
+import (
+	"belajar-go/pkg/apperror"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPickPriceListEntry(t *testing.T) {
+	grosir := sql.NullString{String: "grosir", Valid: true}
+	member := sql.NullString{String: "member", Valid: true}
+	entries := []priceListEntry{
+		{listID: 1, listName: "Umum", minQty: 1, harga: 9000},
+		{listID: 1, listName: "Umum", minQty: 12, harga: 8500},
+		{listID: 2, listName: "Grosir", customerGroup: grosir, minQty: 1, harga: 8000},
+		{listID: 2, listName: "Grosir", customerGroup: grosir, minQty: 24, harga: 7000},
+		{listID: 3, listName: "Grosir Baru", customerGroup: grosir, minQty: 24, harga: 6500},
+		{listID: 4, listName: "Member", customerGroup: member, minQty: 6, harga: 8800},
+	}
+
+	tests := []struct {
+		name     string
+		entries  []priceListEntry
+		quantity int
+		group    sql.NullString
+		want     int // harga, 0 when no entry applies
+	}{
+		{"no entries", nil, 5, grosir, 0},
+		{"no customer uses the group-less list", entries, 5, sql.NullString{}, 9000},
+		{"highest break reached", entries, 12, sql.NullString{}, 8500},
+		{"group list wins over group-less list", entries, 12, grosir, 8000},
+		{"same break in two lists, lowest list ID", entries, 30, grosir, 7000},
+		{"other group falls back to group-less list", entries, 5, member, 9000},
+		{"group list break reached", entries, 6, member, 8800},
+		{"unknown group uses group-less list", entries, 1, sql.NullString{String: "vip", Valid: true}, 9000},
+		{"below every break", entries[1:2], 5, sql.NullString{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pickPriceListEntry(tt.entries, tt.quantity, tt.group)
+			switch {
+			case got == nil && tt.want != 0:
+				t.Errorf("pickPriceListEntry() = nil, want harga %d", tt.want)
+			case got != nil && got.harga != tt.want:
+				t.Errorf("pickPriceListEntry() = %+v, want harga %d", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRevenue(t *testing.T) {
+	tests := []struct {
+		name    string
+		revenue int
+		values  []int
+		want    []int
+	}{
+		{"single component", 15000, []int{20000}, []int{15000}},
+		{"proportional to value", 15000, []int{10000, 5000}, []int{10000, 5000}},
+		{"bundle discount", 12000, []int{10000, 5000}, []int{8000, 4000}},
+		{"last takes the remainder", 10000, []int{1, 1, 1}, []int{3333, 3333, 3334}},
+		{"free components split evenly", 9000, []int{0, 0, 0}, []int{3000, 3000, 3000}},
+		{"free component gets nothing", 8000, []int{6000, 0, 2000}, []int{6000, 0, 2000}},
+		{"free bundle", 0, []int{3000, 2000}, []int{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitRevenue(tt.revenue, tt.values)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitRevenue(%d, %v) = %v, want %v", tt.revenue, tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckModifierSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []*modifierGroup
+		wantErr string
+	}{
+		{"no groups", nil, ""},
+		{"optional group left empty", []*modifierGroup{{name: "Topping", maxSelect: 3}}, ""},
+		{"required group chosen", []*modifierGroup{{name: "Level Pedas", minSelect: 1, maxSelect: 1, selected: 1}}, ""},
+		{"no upper limit", []*modifierGroup{{name: "Topping", selected: 9}}, ""},
+		{
+			"required group missing",
+			[]*modifierGroup{{name: "Level Pedas", minSelect: 1, maxSelect: 1}},
+			"choose at least 1 from Level Pedas for product Mie Goreng",
+		},
+		{
+			"too many chosen",
+			[]*modifierGroup{{name: "Topping", maxSelect: 2, selected: 3}},
+			"choose at most 2 from Topping for product Mie Goreng",
+		},
+		{
+			"first failing group is reported",
+			[]*modifierGroup{
+				{name: "Topping", maxSelect: 2, selected: 1},
+				{name: "Level Pedas", minSelect: 1, maxSelect: 1},
+				{name: "Minuman", maxSelect: 1, selected: 2},
+			},
+			"choose at least 1 from Level Pedas for product Mie Goreng",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkModifierSelection(tt.groups, "Mie Goreng")
+			var appErr *apperror.Error
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("checkModifierSelection() error = %v, want none", err)
+			case tt.wantErr != "" && (!errors.As(err, &appErr) || appErr.Message != tt.wantErr):
+				t.Errorf("checkModifierSelection() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
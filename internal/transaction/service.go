@@ -1,8 +1,11 @@
 package transaction
 
 type Service interface {
-	Checkout(items []CheckoutItem) (*Transaction, error)
+	Checkout(req CheckoutRequest) (*Transaction, error)
+	GetByID(id int) (*Transaction, error)
 	GetDailySalesReport() (*DailySalesReport, error)
+	GetProductSalesReport(from, to string) (*ProductSalesReport, error)
+	GetCategorySalesReport(from, to string) (*CategorySalesReport, error)
 }
 
 type service struct {
@@ -13,10 +16,22 @@ func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) Checkout(items []CheckoutItem) (*Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *service) Checkout(req CheckoutRequest) (*Transaction, error) {
+	return s.repo.CreateTransaction(req)
+}
+
+func (s *service) GetByID(id int) (*Transaction, error) {
+	return s.repo.GetByID(id)
 }
 
 func (s *service) GetDailySalesReport() (*DailySalesReport, error) {
 	return s.repo.GetDailySalesReport()
 }
+
+func (s *service) GetProductSalesReport(from, to string) (*ProductSalesReport, error) {
+	return s.repo.GetProductSalesReport(from, to)
+}
+
+func (s *service) GetCategorySalesReport(from, to string) (*CategorySalesReport, error) {
+	return s.repo.GetCategorySalesReport(from, to)
+}
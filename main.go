@@ -1,15 +1,27 @@
 package main
 
 import (
+	"belajar-go/internal/bulkupdate"
+	"belajar-go/internal/catalog"
 	"belajar-go/internal/category"
+	"belajar-go/internal/customer"
+	"belajar-go/internal/giftcard"
+	"belajar-go/internal/inventory"
+	"belajar-go/internal/modifier"
+	"belajar-go/internal/pos"
+	"belajar-go/internal/pricelist"
+	"belajar-go/internal/pricingrule"
 	"belajar-go/internal/product"
 	"belajar-go/internal/transaction"
 	"belajar-go/pkg/database"
+	"belajar-go/pkg/response"
+	"belajar-go/pkg/storage"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -35,10 +47,66 @@ func main() {
 	categoryHandler := category.NewHandler(categoryService)
 
 	// Initialize Product dependencies
+	imageStorage, err := storage.NewLocal(envOr("IMAGE_DIR", "uploads"))
+	if err != nil {
+		log.Fatalf("Failed to open image storage: %v", err)
+	}
 	productRepo := product.NewRepository(db)
-	productService := product.NewService(productRepo)
+	productService := product.NewService(productRepo, imageStorage)
 	productHandler := product.NewHandler(productService)
 
+	// Apply scheduled price changes in the background
+	stopPriceScheduler := product.StartPriceScheduler(productService, priceSchedulerInterval())
+	defer stopPriceScheduler()
+
+	// Delete orphaned product images in the background
+	stopImageSweeper := product.StartImageSweeper(productService, imageSweepInterval())
+	defer stopImageSweeper()
+
+	// Initialize Customer dependencies
+	customerRepo := customer.NewRepository(db)
+	customerService := customer.NewService(customerRepo)
+	customerHandler := customer.NewHandler(customerService)
+
+	// Initialize Gift Card dependencies
+	giftCardRepo := giftcard.NewRepository(db)
+	giftCardService := giftcard.NewService(giftCardRepo)
+	giftCardHandler := giftcard.NewHandler(giftCardService)
+
+	// Initialize Price List dependencies
+	priceListRepo := pricelist.NewRepository(db)
+	priceListService := pricelist.NewService(priceListRepo)
+	priceListHandler := pricelist.NewHandler(priceListService)
+
+	// Initialize Pricing Rule dependencies
+	pricingRuleRepo := pricingrule.NewRepository(db)
+	pricingRuleService := pricingrule.NewService(pricingRuleRepo)
+	pricingRuleHandler := pricingrule.NewHandler(pricingRuleService)
+
+	// Initialize Modifier dependencies
+	modifierRepo := modifier.NewRepository(db)
+	modifierService := modifier.NewService(modifierRepo)
+	modifierHandler := modifier.NewHandler(modifierService)
+
+	// Initialize Inventory dependencies
+	inventoryRepo := inventory.NewRepository(db)
+	inventoryService := inventory.NewService(inventoryRepo)
+	inventoryHandler := inventory.NewHandler(inventoryService)
+
+	// Initialize Bulk Update dependencies
+	bulkUpdateRepo := bulkupdate.NewRepository(db)
+	bulkUpdateService := bulkupdate.NewService(bulkUpdateRepo)
+	bulkUpdateHandler := bulkupdate.NewHandler(bulkUpdateService)
+
+	// Initialize Catalog dependencies
+	catalogService := catalog.NewService(db, categoryRepo, productRepo)
+	catalogHandler := catalog.NewHandler(catalogService)
+
+	// Initialize POS Layout dependencies
+	posRepo := pos.NewRepository(db)
+	posService := pos.NewService(posRepo)
+	posHandler := pos.NewHandler(posService)
+
 	// Initialize Transaction dependencies
 	transactionRepo := transaction.NewRepository(db)
 	transactionService := transaction.NewService(transactionRepo)
@@ -50,22 +118,115 @@ func main() {
 	// Category Routes
 	mux.HandleFunc("GET /categories", categoryHandler.GetAll)
 	mux.HandleFunc("POST /categories", categoryHandler.Create)
+	mux.HandleFunc("GET /categories/tree", categoryHandler.GetTree)
 	mux.HandleFunc("GET /categories/{id}", categoryHandler.GetByID)
 	mux.HandleFunc("PUT /categories/{id}", categoryHandler.Update)
+	mux.HandleFunc("PATCH /categories/{id}", categoryHandler.Patch)
 	mux.HandleFunc("DELETE /categories/{id}", categoryHandler.Delete)
+	mux.HandleFunc("POST /categories/{id}/restore", categoryHandler.Restore)
+	mux.HandleFunc("POST /categories/{id}/move", categoryHandler.Move)
 
 	// Product Routes
 	mux.HandleFunc("GET /products", productHandler.GetAll)
 	mux.HandleFunc("POST /products", productHandler.Create)
 	mux.HandleFunc("GET /products/{id}", productHandler.GetByID)
 	mux.HandleFunc("PUT /products/{id}", productHandler.Update)
+	mux.HandleFunc("PATCH /products/{id}", productHandler.Patch)
 	mux.HandleFunc("DELETE /products/{id}", productHandler.Delete)
+	mux.HandleFunc("POST /products/{id}/restore", productHandler.Restore)
+	mux.HandleFunc("POST /products/import", productHandler.Import)
+	mux.HandleFunc("GET /products/barcode/{code}", productHandler.GetByBarcode)
+	mux.HandleFunc("GET /products/search", productHandler.Search)
+	mux.HandleFunc("POST /products/{id}/price-schedules", productHandler.SchedulePrice)
+	mux.HandleFunc("POST /products/{id}/variants", productHandler.CreateVariant)
+	mux.HandleFunc("POST /products/{id}/units", productHandler.CreateUnit)
+	mux.HandleFunc("DELETE /products/{id}/units/{unitId}", productHandler.DeleteUnit)
+	mux.HandleFunc("PUT /products/{id}/components", productHandler.ReplaceComponents)
+	mux.HandleFunc("PUT /products/{id}/recipe", productHandler.ReplaceRecipe)
+	mux.HandleFunc("PUT /products/{id}/image", productHandler.UploadImage)
+	mux.HandleFunc("DELETE /products/{id}/image", productHandler.DeleteImage)
+	mux.HandleFunc("PUT /products/{id}/modifier-groups", modifierHandler.SetProductGroups)
+
+	// GET sub-resources of a product share one pattern, otherwise the router
+	// rejects them as ambiguous with GET /products/barcode/{code}
+	mux.HandleFunc("GET /products/{id}/{resource}", subresource(map[string]http.HandlerFunc{
+		"price":           productHandler.GetPriceAt,
+		"price-history":   productHandler.GetPriceHistory,
+		"units":           productHandler.GetUnits,
+		"components":      productHandler.GetComponents,
+		"recipe":          productHandler.GetRecipe,
+		"image":           productHandler.GetImage,
+		"thumbnail":       productHandler.GetThumbnail,
+		"modifier-groups": modifierHandler.GetProductGroups,
+	}))
+
+	// Customer Routes
+	mux.HandleFunc("GET /customers", customerHandler.GetAll)
+	mux.HandleFunc("POST /customers", customerHandler.Create)
+	mux.HandleFunc("GET /customers/{id}", customerHandler.GetByID)
+	mux.HandleFunc("PUT /customers/{id}", customerHandler.Update)
+	mux.HandleFunc("GET /customers/{id}/ledger", customerHandler.GetLedger)
+	mux.HandleFunc("POST /customers/{id}/repayments", customerHandler.Repay)
+
+	// Gift Card Routes
+	mux.HandleFunc("GET /gift-cards/{code}", giftCardHandler.GetByCode)
+	mux.HandleFunc("GET /gift-cards/{code}/movements", giftCardHandler.GetHistory)
+
+	// Price List Routes
+	mux.HandleFunc("GET /price-lists", priceListHandler.GetAll)
+	mux.HandleFunc("POST /price-lists", priceListHandler.Create)
+	mux.HandleFunc("GET /price-lists/{id}", priceListHandler.GetByID)
+	mux.HandleFunc("PUT /price-lists/{id}", priceListHandler.Update)
+	mux.HandleFunc("DELETE /price-lists/{id}", priceListHandler.Delete)
+	mux.HandleFunc("POST /price-lists/{id}/items", priceListHandler.AddItem)
+	mux.HandleFunc("DELETE /price-lists/{id}/items/{itemId}", priceListHandler.DeleteItem)
+
+	// Pricing Rule Routes
+	mux.HandleFunc("GET /pricing-rules", pricingRuleHandler.GetAll)
+	mux.HandleFunc("POST /pricing-rules", pricingRuleHandler.Create)
+	mux.HandleFunc("GET /pricing-rules/{id}", pricingRuleHandler.GetByID)
+	mux.HandleFunc("PUT /pricing-rules/{id}", pricingRuleHandler.Update)
+	mux.HandleFunc("DELETE /pricing-rules/{id}", pricingRuleHandler.Delete)
+
+	// Modifier Routes
+	mux.HandleFunc("GET /modifier-groups", modifierHandler.GetAll)
+	mux.HandleFunc("POST /modifier-groups", modifierHandler.Create)
+	mux.HandleFunc("GET /modifier-groups/{id}", modifierHandler.GetByID)
+	mux.HandleFunc("PUT /modifier-groups/{id}", modifierHandler.Update)
+	mux.HandleFunc("DELETE /modifier-groups/{id}", modifierHandler.Delete)
+	mux.HandleFunc("POST /modifier-groups/{id}/modifiers", modifierHandler.AddModifier)
+	mux.HandleFunc("DELETE /modifier-groups/{id}/modifiers/{modifierId}", modifierHandler.DeleteModifier)
+
+	// Stock Count Routes
+	mux.HandleFunc("GET /stock-counts", inventoryHandler.GetStockCounts)
+	mux.HandleFunc("POST /stock-counts", inventoryHandler.CreateStockCount)
+
+	// Bulk Update Routes
+	mux.HandleFunc("GET /bulk-updates", bulkUpdateHandler.GetAll)
+	mux.HandleFunc("GET /bulk-updates/{id}", bulkUpdateHandler.GetByID)
+	mux.HandleFunc("POST /bulk-updates/price", bulkUpdateHandler.UpdatePrices)
+	mux.HandleFunc("POST /bulk-updates/stock", bulkUpdateHandler.UpdateStock)
+
+	// Catalog Routes
+	mux.HandleFunc("GET /catalog/export", catalogHandler.Export)
+	mux.HandleFunc("POST /catalog/import", catalogHandler.Import)
+
+	// POS Layout Routes
+	mux.HandleFunc("GET /pos/layout", posHandler.GetLayout)
+	mux.HandleFunc("PUT /pos/layout/categories", posHandler.ReorderCategories)
+	mux.HandleFunc("PUT /pos/layout/products", posHandler.ReorderProducts)
+	mux.HandleFunc("PUT /pos/favorites", posHandler.SetFavorites)
 
 	// Transaction Routes
 	mux.HandleFunc("POST /api/checkout", transactionHandler.Checkout)
+	mux.HandleFunc("GET /api/transactions/{id}", transactionHandler.GetByID)
 
 	// Report Routes
 	mux.HandleFunc("GET /api/report/hari-ini", transactionHandler.GetDailySalesReport)
+	mux.HandleFunc("GET /api/report/piutang", customerHandler.GetAgingReport)
+	mux.HandleFunc("GET /api/report/produk", transactionHandler.GetProductSalesReport)
+	mux.HandleFunc("GET /api/report/kategori", transactionHandler.GetCategorySalesReport)
+	mux.HandleFunc("GET /api/report/bahan", inventoryHandler.GetIngredientUsageReport)
 
 	// Health Check Route
 	mux.HandleFunc("GET /health", healthCheck)
@@ -90,3 +251,38 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 		"message": "Server is running smoothly",
 	})
 }
+
+// subresource dispatches to the handler registered for the {resource} path value.
+func subresource(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.PathValue("resource")]
+		if !ok {
+			response.Error(w, http.StatusNotFound, "Not found")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func priceSchedulerInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("PRICE_SCHEDULER_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Minute
+	}
+	return interval
+}
+
+func imageSweepInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("IMAGE_SWEEP_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+	return interval
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
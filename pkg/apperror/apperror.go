@@ -0,0 +1,58 @@
+package apperror
+
+import "fmt"
+
+// Kind classifies an error so handlers can answer with the right status
+// code without inspecting messages.
+type Kind int
+
+const (
+	KindNotFound Kind = iota + 1
+	KindConflict
+	KindValidation
+	KindInsufficientStock
+	KindPreconditionFailed
+)
+
+// Error is a domain error whose Message is safe to show to clients.
+type Error struct {
+	Kind    Kind
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// NotFound reports a missing resource.
+func NotFound(format string, args ...interface{}) error {
+	return newError(KindNotFound, format, args...)
+}
+
+// Conflict reports a request that clashes with the current state, such as a
+// duplicate SKU or an already activated gift card.
+func Conflict(format string, args ...interface{}) error {
+	return newError(KindConflict, format, args...)
+}
+
+// Validation reports a request that is well formed but breaks a business
+// rule, such as a recipe on a bundle.
+func Validation(format string, args ...interface{}) error {
+	return newError(KindValidation, format, args...)
+}
+
+// InsufficientStock reports a sale that needs more stock or balance than is
+// available.
+func InsufficientStock(format string, args ...interface{}) error {
+	return newError(KindInsufficientStock, format, args...)
+}
+
+// PreconditionFailed reports a conditional request whose precondition no
+// longer holds.
+func PreconditionFailed(format string, args ...interface{}) error {
+	return newError(KindPreconditionFailed, format, args...)
+}
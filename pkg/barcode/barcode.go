@@ -0,0 +1,39 @@
+package barcode
+
+import "fmt"
+
+// Validate checks that code is an EAN-8, UPC-A (12 digits) or EAN-13
+// barcode with a correct check digit.
+func Validate(code string) error {
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return fmt.Errorf("barcode %s must have 8 (EAN-8), 12 (UPC-A) or 13 (EAN-13) digits", code)
+	}
+
+	digits := make([]int, len(code))
+	for i, c := range code {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("barcode %s must contain digits only", code)
+		}
+		digits[i] = int(c - '0')
+	}
+
+	if CheckDigit(digits[:len(digits)-1]) != digits[len(digits)-1] {
+		return fmt.Errorf("barcode %s has an invalid check digit", code)
+	}
+
+	return nil
+}
+
+// CheckDigit computes the GTIN check digit for the given payload digits.
+// Weights alternate 3 and 1 starting from the rightmost payload digit.
+func CheckDigit(payload []int) int {
+	sum := 0
+	weight := 3
+	for i := len(payload) - 1; i >= 0; i-- {
+		sum += payload[i] * weight
+		weight = 4 - weight
+	}
+	return (10 - sum%10) % 10
+}
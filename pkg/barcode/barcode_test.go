@@ -0,0 +1,57 @@
+package barcode
+
+import "testing"
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    int
+	}{
+		{"EAN-8", "9638507", 4},
+		{"UPC-A", "03600029145", 2},
+		{"EAN-13", "400638133393", 1},
+		{"EAN-13 check digit 0", "501234567890", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := make([]int, len(tt.payload))
+			for i, c := range tt.payload {
+				payload[i] = int(c - '0')
+			}
+			if got := CheckDigit(payload); got != tt.want {
+				t.Errorf("CheckDigit(%s) = %d, want %d", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		wantErr bool
+	}{
+		{"EAN-8", "96385074", false},
+		{"UPC-A", "036000291452", false},
+		{"EAN-13", "4006381333931", false},
+		{"EAN-8 wrong check digit", "96385075", true},
+		{"UPC-A wrong check digit", "036000291453", true},
+		{"EAN-13 wrong check digit", "4006381333932", true},
+		{"empty", "", true},
+		{"too short", "1234567", true},
+		{"between lengths", "1234567890", true},
+		{"too long", "40063813339310", true},
+		{"not digits", "40063813339A1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package database
+
+import "database/sql"
+
+// Conn runs the queries of a repository on the database, or inside a
+// transaction begun by a caller that spans several repository calls.
+type Conn struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewConn(db *sql.DB) Conn {
+	return Conn{db: db}
+}
+
+// WithTx returns a Conn that runs everything in tx. Begin then hands out tx
+// itself and Commit and Rollback leave it alone: the caller that began tx
+// commits or rolls it back.
+func (c Conn) WithTx(tx *sql.Tx) Conn {
+	return Conn{db: c.db, tx: tx}
+}
+
+func (c Conn) Begin() (*sql.Tx, error) {
+	if c.tx != nil {
+		return c.tx, nil
+	}
+	return c.db.Begin()
+}
+
+func (c Conn) Commit(tx *sql.Tx) error {
+	if c.tx != nil {
+		return nil
+	}
+	return tx.Commit()
+}
+
+func (c Conn) Rollback(tx *sql.Tx) {
+	if c.tx == nil {
+		tx.Rollback()
+	}
+}
+
+func (c Conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if c.tx != nil {
+		return c.tx.Exec(query, args...)
+	}
+	return c.db.Exec(query, args...)
+}
+
+func (c Conn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if c.tx != nil {
+		return c.tx.Query(query, args...)
+	}
+	return c.db.Query(query, args...)
+}
+
+func (c Conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	if c.tx != nil {
+		return c.tx.QueryRow(query, args...)
+	}
+	return c.db.QueryRow(query, args...)
+}
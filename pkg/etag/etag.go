@@ -0,0 +1,81 @@
+package etag
+
+import (
+	"belajar-go/pkg/apperror"
+	"belajar-go/pkg/response"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrPreconditionFailed is returned when the If-Match tag no longer matches
+// the stored version, i.e. someone else changed the resource first.
+var ErrPreconditionFailed = apperror.PreconditionFailed("resource was modified by another request, reload and try again")
+
+// Version is the ETag of a row with a version column.
+func Version(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// Hash is a weak ETag over the JSON encoding of v, for list responses.
+func Hash(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// Matches reports whether an If-None-Match header value matches tag, using
+// weak comparison. "*" matches any tag.
+func Matches(header, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesStrong reports whether an If-Match header value matches tag, using
+// the strong comparison If-Match requires: a weak tag on either side never
+// matches. "*" matches any tag.
+func MatchesStrong(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (candidate == tag && !strings.HasPrefix(tag, "W/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModified sets the ETag header and, when If-None-Match matches it,
+// writes 304 Not Modified and returns true.
+func NotModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+
+	if header := r.Header.Get("If-None-Match"); header != "" && Matches(header, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// IfMatch returns the If-Match header of a write request. When it is
+// missing it writes 428 Precondition Required and returns false.
+func IfMatch(w http.ResponseWriter, r *http.Request) (string, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		response.Error(w, http.StatusPreconditionRequired, "If-Match header is required")
+		return "", false
+	}
+	return header, true
+}
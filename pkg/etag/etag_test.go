@@ -0,0 +1,88 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		tag    string
+		want   bool
+	}{
+		{`"3"`, `"3"`, true},
+		{`"2"`, `"3"`, false},
+		{`*`, `"3"`, true},
+		{`"1", "3"`, `"3"`, true},
+		{`"1","2"`, `"3"`, false},
+		{`W/"3"`, `"3"`, true},
+		{`"abc"`, `W/"abc"`, true},
+		{`W/"abc"`, `W/"abc"`, true},
+		{`3`, `"3"`, false},
+	}
+
+	for _, tt := range tests {
+		if got := Matches(tt.header, tt.tag); got != tt.want {
+			t.Errorf("Matches(%s, %s) = %v, want %v", tt.header, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesStrong(t *testing.T) {
+	tests := []struct {
+		header string
+		tag    string
+		want   bool
+	}{
+		{`"3"`, `"3"`, true},
+		{`"2"`, `"3"`, false},
+		{`*`, `"3"`, true},
+		{`"1", "3"`, `"3"`, true},
+		{`W/"3"`, `"3"`, false},
+		{`W/"abc"`, `W/"abc"`, false},
+		{`"abc"`, `W/"abc"`, false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesStrong(tt.header, tt.tag); got != tt.want {
+			t.Errorf("MatchesStrong(%s, %s) = %v, want %v", tt.header, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", "", false},
+		{"same tag", `W/"abc"`, true},
+		{"strong form of the tag", `"abc"`, true},
+		{"other tag", `W/"def"`, false},
+		{"any tag", "*", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/categories", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+
+			got := NotModified(w, r, `W/"abc"`)
+			if got != tt.want {
+				t.Errorf("NotModified() = %v, want %v", got, tt.want)
+			}
+			if tag := w.Header().Get("ETag"); tag != `W/"abc"` {
+				t.Errorf("ETag header = %q, want %q", tag, `W/"abc"`)
+			}
+			if tt.want && w.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusNotModified)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail scales img down to fit in a size×size square, keeping its
+// aspect ratio. Each target pixel is the average of the source pixels it
+// covers, which stays sharp without the aliasing of nearest-neighbour
+// scaling. Transparent areas are flattened onto white so the result can be
+// saved as JPEG.
+func Thumbnail(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := span(y, h, th)
+		for x := 0; x < tw; x++ {
+			x0, x1 := span(x, w, tw)
+
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// RGBA is premultiplied, so adding the missing alpha
+					// blends the pixel onto white
+					cr, cg, cb, ca := img.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r += uint64(cr + 0xffff - ca)
+					g += uint64(cg + 0xffff - ca)
+					bl += uint64(cb + 0xffff - ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+
+	return dst
+}
+
+// span returns the source pixels [from, to) behind target pixel i when n
+// source pixels are scaled to m.
+func span(i, n, m int) (int, int) {
+	from, to := i*n/m, (i+1)*n/m
+	if to == from {
+		to = from + 1
+	}
+	return from, to
+}
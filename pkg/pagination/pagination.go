@@ -0,0 +1,85 @@
+package pagination
+
+import (
+	"belajar-go/pkg/response"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Params is an offset page request. Sort is one of the keys whitelisted by
+// the caller; Desc is set when the key was prefixed with "-".
+type Params struct {
+	Page    int
+	PerPage int
+	Sort    string
+	Desc    bool
+}
+
+// Parse reads page, per_page and sort (e.g. "sort=-harga") from the query
+// string. sortable maps the accepted sort keys to their SQL expressions and
+// defaultSort is used when no sort is given.
+func Parse(q url.Values, sortable map[string]string, defaultSort string) (Params, error) {
+	p := Params{Page: 1, PerPage: DefaultPerPage, Sort: defaultSort}
+
+	if v := q.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return p, fmt.Errorf("page must be a positive number")
+		}
+		p.Page = page
+	}
+
+	if v := q.Get("per_page"); v != "" {
+		perPage, err := strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > MaxPerPage {
+			return p, fmt.Errorf("per_page must be between 1 and %d", MaxPerPage)
+		}
+		p.PerPage = perPage
+	}
+
+	if v := q.Get("sort"); v != "" {
+		p.Desc = strings.HasPrefix(v, "-")
+		p.Sort = strings.TrimPrefix(v, "-")
+		if _, ok := sortable[p.Sort]; !ok {
+			return p, fmt.Errorf("cannot sort by %s", p.Sort)
+		}
+	}
+
+	return p, nil
+}
+
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// OrderBy returns the ORDER BY clause for the sort key, with the tiebreaker
+// appended so pages stay stable when sort values repeat.
+func (p Params) OrderBy(sortable map[string]string, tiebreaker string) string {
+	direction := "ASC"
+	if p.Desc {
+		direction = "DESC"
+	}
+
+	clause := fmt.Sprintf(" ORDER BY %s %s", sortable[p.Sort], direction)
+	if sortable[p.Sort] != tiebreaker {
+		clause += fmt.Sprintf(", %s %s", tiebreaker, direction)
+	}
+
+	return clause
+}
+
+func (p Params) Meta(total int) *response.Meta {
+	return &response.Meta{
+		Page:       p.Page,
+		PerPage:    p.PerPage,
+		Total:      total,
+		TotalPages: (total + p.PerPage - 1) / p.PerPage,
+	}
+}
@@ -0,0 +1,70 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	sortable := map[string]string{"id": "p.id", "harga": "p.harga"}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    Params
+		wantErr bool
+	}{
+		{"defaults", "", Params{Page: 1, PerPage: DefaultPerPage, Sort: "id"}, false},
+		{"page and per_page", "page=3&per_page=50", Params{Page: 3, PerPage: 50, Sort: "id"}, false},
+		{"max per_page", "per_page=100", Params{Page: 1, PerPage: MaxPerPage, Sort: "id"}, false},
+		{"ascending sort", "sort=harga", Params{Page: 1, PerPage: DefaultPerPage, Sort: "harga"}, false},
+		{"descending sort", "sort=-harga", Params{Page: 1, PerPage: DefaultPerPage, Sort: "harga", Desc: true}, false},
+		{"page zero", "page=0", Params{}, true},
+		{"page not a number", "page=abc", Params{}, true},
+		{"per_page over max", "per_page=101", Params{}, true},
+		{"per_page zero", "per_page=0", Params{}, true},
+		{"unknown sort", "sort=-stok", Params{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Parse(q, sortable, "id")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) = %+v, want error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	sortable := map[string]string{"id": "p.id", "harga": "p.harga"}
+
+	tests := []struct {
+		params Params
+		want   string
+	}{
+		{Params{Sort: "id"}, " ORDER BY p.id ASC"},
+		{Params{Sort: "harga"}, " ORDER BY p.harga ASC, p.id ASC"},
+		{Params{Sort: "harga", Desc: true}, " ORDER BY p.harga DESC, p.id DESC"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.params.OrderBy(sortable, "p.id"); got != tt.want {
+			t.Errorf("OrderBy(%+v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Document is a JSON Merge Patch (RFC 7396) body. Keys that are omitted
+// leave the field untouched and an explicit null clears it.
+type Document map[string]json.RawMessage
+
+// Decode unmarshals the value of key into dst. It reports whether the value
+// was null, which is only accepted when the field is nullable.
+func (d Document) Decode(key string, dst interface{}, nullable bool) (bool, error) {
+	raw := bytes.TrimSpace(d[key])
+	if bytes.Equal(raw, []byte("null")) {
+		if !nullable {
+			return true, fmt.Errorf("%s cannot be null", key)
+		}
+		return true, nil
+	}
+
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return false, fmt.Errorf("invalid value for %s", key)
+	}
+
+	return false, nil
+}
+
+// Assignments builds the SET list of an UPDATE for the given columns, in a
+// stable order, with placeholders numbered from $1. A nil value sets the
+// column to NULL.
+func Assignments(columns map[string]interface{}) (string, []interface{}) {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sets := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		sets[i] = fmt.Sprintf("%s = $%d", name, i+1)
+		args[i] = columns[name]
+	}
+
+	return strings.Join(sets, ", "), args
+}
@@ -1,14 +1,35 @@
 package response
 
 import (
+	"belajar-go/pkg/apperror"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 )
 
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Success bool         `json:"success"`
+	Message string       `json:"message,omitempty"`
+	Data    interface{}  `json:"data,omitempty"`
+	Meta    *Meta        `json:"meta,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError explains why one field of a request body was rejected. Field
+// is the JSON path of the value, e.g. items[0].quantity.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Meta describes the page returned by a paginated list endpoint.
+type Meta struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
 }
 
 func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
@@ -24,9 +45,66 @@ func Success(w http.ResponseWriter, statusCode int, data interface{}) {
 	})
 }
 
+func Paginated(w http.ResponseWriter, statusCode int, data interface{}, meta *Meta) {
+	JSON(w, statusCode, Response{
+		Success: true,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
 func Error(w http.ResponseWriter, statusCode int, message string) {
 	JSON(w, statusCode, Response{
 		Success: false,
 		Message: message,
 	})
 }
+
+// ValidationFailed writes a 422 listing every rejected field.
+func ValidationFailed(w http.ResponseWriter, errs []FieldError) {
+	JSON(w, http.StatusUnprocessableEntity, Response{
+		Success: false,
+		Message: "Validation failed",
+		Errors:  errs,
+	})
+}
+
+// fieldErrors is implemented by validation.Errors, which cannot be imported
+// here without an import cycle.
+type fieldErrors interface {
+	FieldErrors() []FieldError
+}
+
+// FromError writes err with the status code of its kind. Errors that are not
+// domain errors are logged and reported as a generic 500, so database
+// messages never reach clients.
+func FromError(w http.ResponseWriter, err error) {
+	var fe fieldErrors
+	if errors.As(err, &fe) {
+		ValidationFailed(w, fe.FieldErrors())
+		return
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		Error(w, statusCode(appErr.Kind), appErr.Message)
+		return
+	}
+
+	log.Printf("internal error: %v", err)
+	Error(w, http.StatusInternalServerError, "Internal server error")
+}
+
+func statusCode(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindNotFound:
+		return http.StatusNotFound
+	case apperror.KindConflict, apperror.KindInsufficientStock:
+		return http.StatusConflict
+	case apperror.KindValidation:
+		return http.StatusUnprocessableEntity
+	case apperror.KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusInternalServerError
+}
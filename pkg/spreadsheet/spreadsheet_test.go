@@ -0,0 +1,138 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"belajar-go/pkg/apperror"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// xlsxFile builds a minimal workbook with sheetData as its only worksheet.
+func xlsxFile(t *testing.T, sheetData string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>nama</t></si><si><r><t>Teh </t></r><r><t>Botol</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheetData string
+		want      [][]string
+		wantErr   bool
+		wantLimit bool
+	}{
+		{
+			name: "shared, inline and number cells",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>harga</t></is></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>1</v></c><c r="B2"><v>5000</v></c></row>`,
+			want: [][]string{{"nama", "harga"}, {"Teh Botol", "5000"}},
+		},
+		{
+			name:      "empty rows and cells are kept",
+			sheetData: `<row r="1"><c r="A1"><v>1</v></c></row><row r="3"><c r="C3"><v>2</v></c></row>`,
+			want:      [][]string{{"1"}, nil, {"", "", "2"}},
+		},
+		{
+			name:      "cells without a reference",
+			sheetData: `<row><c><v>1</v></c><c><v>2</v></c></row>`,
+			want:      [][]string{{"1", "2"}},
+		},
+		{
+			name:      "reference without column letters",
+			sheetData: `<row r="12"><c r="12"><v>1</v></c></row>`,
+			wantErr:   true,
+		},
+		{
+			name:      "bad shared string index",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>9</v></c></row>`,
+			wantErr:   true,
+		},
+		{
+			name:      "row past MaxRows",
+			sheetData: `<row r="1000000000"><c r="A1000000000"><v>1</v></c></row>`,
+			wantErr:   true,
+			wantLimit: true,
+		},
+		{
+			name:      "column past MaxColumns",
+			sheetData: `<row r="1"><c r="XFDZZZZ1"><v>1</v></c></row>`,
+			wantErr:   true,
+			wantLimit: true,
+		},
+		{
+			name:      "last allowed column",
+			sheetData: `<row r="1"><c r="IV1"><v>1</v></c></row>`,
+			want:      [][]string{append(make([]string, MaxColumns-1), "1")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := xlsxFile(t, tt.sheetData)
+			rows, err := ReadXLSX(bytes.NewReader(data), int64(len(data)))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ReadXLSX() = %v, want error", rows)
+				}
+				var appErr *apperror.Error
+				if isLimit := errors.As(err, &appErr); isLimit != tt.wantLimit {
+					t.Errorf("ReadXLSX() error = %v, limit error %v, want %v", err, isLimit, tt.wantLimit)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadXLSX() error = %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("ReadXLSX() = %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{"comma", "nama,harga\nTeh,5000\n", [][]string{{"nama", "harga"}, {"Teh", "5000"}}},
+		{"semicolon", "nama;harga\nTeh, manis;5000\n", [][]string{{"nama", "harga"}, {"Teh, manis", "5000"}}},
+		{"byte order mark", "\xef\xbb\xbfnama,harga\n", [][]string{{"nama", "harga"}}},
+		{"ragged rows", "nama,harga,stok\nTeh\n", [][]string{{"nama", "harga", "stok"}, {"Teh"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadCSV(bytes.NewReader([]byte(tt.data)))
+			if err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("ReadCSV() = %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when no file is stored under a key.
+var ErrNotFound = errors.New("file not found")
+
+// Object is a stored file.
+type Object struct {
+	Key     string
+	ModTime time.Time
+}
+
+// Storage keeps files under slash-separated keys such as
+// "products/12/4f2a9c.jpg". Local stores them on disk; another backend,
+// such as an object store, only has to provide these operations.
+type Storage interface {
+	Put(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes a file. Deleting a key that is not stored is not an
+	// error, so cleanups can be retried.
+	Delete(key string) error
+	// List returns every file whose key starts with prefix, which must be
+	// a directory such as "products".
+	List(prefix string) ([]Object, error)
+}
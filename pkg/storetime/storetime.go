@@ -0,0 +1,51 @@
+package storetime
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	// Embed the timezone database; the alpine runtime image has none
+	_ "time/tzdata"
+)
+
+const defaultTimezone = "Asia/Jakarta"
+
+var (
+	location *time.Location
+	once     sync.Once
+)
+
+// Location returns the store timezone configured by STORE_TIMEZONE,
+// falling back to Asia/Jakarta.
+func Location() *time.Location {
+	once.Do(func() {
+		name := os.Getenv("STORE_TIMEZONE")
+		if name == "" {
+			name = defaultTimezone
+		}
+
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("Warning: invalid STORE_TIMEZONE %q, using UTC", name)
+			loc = time.UTC
+		}
+		location = loc
+	})
+
+	return location
+}
+
+// Now returns the current time in the store timezone.
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// ISOWeekday returns the ISO day of the week of t (1 = Monday, 7 = Sunday).
+func ISOWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"belajar-go/pkg/response"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error codes returned in response.FieldError.Code.
+const (
+	CodeRequired  = "required"
+	CodeMin       = "min"
+	CodeMax       = "max"
+	CodeInvalid   = "invalid"
+	CodeDuplicate = "duplicate"
+	CodeNotFound  = "not_found"
+)
+
+// Errors is the list of rejected fields of one request. It is also an
+// error so repositories can report fields that only the database can check,
+// such as a category_id that does not exist.
+type Errors []response.FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FieldErrors lets response.FromError write e as a 422.
+func (e Errors) FieldErrors() []response.FieldError {
+	return e
+}
+
+// Field returns Errors holding a single field error.
+func Field(field, code, message string) Errors {
+	return Errors{{Field: field, Code: code, Message: message}}
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Validator collects field errors so a request reports all of its problems
+// at once instead of stopping at the first.
+type Validator struct {
+	errs Errors
+}
+
+// Add records an error for field.
+func (v *Validator) Add(field, code, message string) {
+	v.errs = append(v.errs, response.FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check records an error for field unless ok is true.
+func (v *Validator) Check(ok bool, field, code, message string) {
+	if !ok {
+		v.Add(field, code, message)
+	}
+}
+
+// Required rejects an empty or blank string.
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, CodeRequired, field+" is required")
+}
+
+// Min rejects values below min.
+func (v *Validator) Min(field string, value, min int) {
+	v.Check(value >= min, field, CodeMin, fmt.Sprintf("%s must be at least %d", field, min))
+}
+
+// Range rejects values outside [min, max].
+func (v *Validator) Range(field string, value, min, max int) {
+	if value < min {
+		v.Add(field, CodeMin, fmt.Sprintf("%s must be between %d and %d", field, min, max))
+	} else if value > max {
+		v.Add(field, CodeMax, fmt.Sprintf("%s must be between %d and %d", field, min, max))
+	}
+}
+
+// OneOf rejects a value that is not one of the allowed values.
+func (v *Validator) OneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.Add(field, CodeInvalid, fmt.Sprintf("%s must be one of %s", field, strings.Join(allowed, ", ")))
+}
+
+// MaxLength rejects strings longer than max characters.
+func (v *Validator) MaxLength(field, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, CodeMax, fmt.Sprintf("%s must be at most %d characters", field, max))
+}
+
+// Color rejects a value that is not a hex color such as #1E88E5.
+func (v *Validator) Color(field, value string) {
+	v.Check(hexColor.MatchString(value), field, CodeInvalid, field+" must be a hex color such as #1E88E5")
+}
+
+// Errors returns the collected errors, or nil when the request is valid.
+func (v *Validator) Errors() Errors {
+	return v.errs
+}